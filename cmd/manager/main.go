@@ -12,6 +12,7 @@ import (
 
 	"github.com/appsody-operator/pkg/apis"
 	"github.com/appsody-operator/pkg/controller"
+	appsodyutils "github.com/appsody-operator/pkg/utils"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/leader"
@@ -62,6 +63,11 @@ func main() {
 
 	printVersion()
 
+	if _, err := appsodyutils.GetUnknownStackPolicy(); err != nil {
+		log.Error(err, "Invalid operator configuration")
+		os.Exit(1)
+	}
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
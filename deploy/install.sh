@@ -0,0 +1,15 @@
+#!/bin/bash
+
+# Installs the operator from the manifests of this directory into the namespace given as the first argument.
+# The ClusterRoleBindings name the service account of the operator with its namespace, which is substituted here.
+set -e
+
+NAMESPACE=${1:?Usage: $0 <namespace>}
+DIR=$(dirname "$0")
+
+for crd in "$DIR"/crds/*_crd.yaml; do
+    kubectl apply -f "$crd"
+done
+kubectl apply -n "$NAMESPACE" -f "$DIR/service_account.yaml" -f "$DIR/role.yaml" -f "$DIR/stack_defaults.yaml"
+sed "s/REPLACE_NAMESPACE/$NAMESPACE/g" "$DIR/role_binding.yaml" | kubectl apply -n "$NAMESPACE" -f -
+kubectl apply -n "$NAMESPACE" -f "$DIR/operator.yaml"
@@ -0,0 +1,23 @@
+#!/bin/bash
+
+# Regenerates the daily release manifests from the manifests under deploy. Run it whenever they change.
+set -e
+
+DEPLOY=$(dirname "$0")/..
+DAILY=$(dirname "$0")/daily
+
+# concat prints the given files separated by YAML document separators
+concat() {
+    local separator=""
+    for file in "$@"; do
+        printf "%s" "$separator"
+        cat "$file"
+        # Files may lack a trailing newline
+        [ -z "$(tail -c 1 "$file")" ] || echo
+        separator=$'---\n'
+    done
+}
+
+concat "$DEPLOY"/crds/*_crd.yaml > "$DAILY/appsody-app-crd.yaml"
+concat "$DEPLOY/stack_defaults.yaml" "$DEPLOY/service_account.yaml" "$DEPLOY/role.yaml" \
+    "$DEPLOY/role_binding.yaml" "$DEPLOY/operator.yaml" > "$DAILY/appsody-app-operator.yaml"
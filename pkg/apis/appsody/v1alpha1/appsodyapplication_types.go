@@ -15,6 +15,7 @@ type AppsodyApplicationSpec struct {
 	Autoscaling          *AppsodyApplicationAutoScaling `json:"autoscaling,omitempty"`
 	PullPolicy           *corev1.PullPolicy             `json:"pullPolicy,omitempty"`
 	PullSecret           *string                        `json:"pullSecret,omitempty"`
+	PullSecrets          []string                       `json:"pullSecrets,omitempty"`
 	Volumes              []corev1.Volume                `json:"volumes,omitempty"`
 	VolumeMounts         []corev1.VolumeMount           `json:"volumeMounts,omitempty"`
 	ResourceConstraints  *corev1.ResourceRequirements   `json:"resourceConstraints,omitempty"`
@@ -24,11 +25,72 @@ type AppsodyApplicationSpec struct {
 	Expose               *bool                          `json:"expose,omitempty"`
 	EnvFrom              []corev1.EnvFromSource         `json:"envFrom,omitempty"`
 	Env                  []corev1.EnvVar                `json:"env,omitempty"`
+	RolloutExclusions    []ConfigReference              `json:"rolloutExclusions,omitempty"`
+	Config               *AppsodyApplicationConfig      `json:"config,omitempty"`
+	Hooks                *AppsodyApplicationHooks       `json:"hooks,omitempty"`
 	ServiceAccountName   *string                        `json:"serviceAccountName,omitempty"`
+	PatchServiceAccount  *bool                          `json:"patchServiceAccount,omitempty"`
 	Architecture         []string                       `json:"architecture,omitempty"`
+	Scheduling           *AppsodyApplicationScheduling  `json:"scheduling,omitempty"`
+	SecurityContext      *AppsodyApplicationSecurity    `json:"securityContext,omitempty"`
 	Storage              *AppsodyApplicationStorage     `json:"storage,omitempty"`
 	CreateKnativeService *bool                          `json:"createKnativeService,omitempty"`
-	Stack                string                         `json:"stack"`
+	Knative              *AppsodyApplicationKnative     `json:"knative,omitempty"`
+	Stack                string                         `json:"stack,omitempty"`
+
+	// +kubebuilder:validation:Enum=Deployment,StatefulSet,DaemonSet,CronJob,KnativeService
+	WorkloadKind WorkloadKind `json:"workloadKind,omitempty"`
+	// Schedule of the CronJob in Cron format, required when workloadKind is CronJob.
+	Schedule string `json:"schedule,omitempty"`
+
+	ImagePolicy *AppsodyApplicationImagePolicy `json:"imagePolicy,omitempty"`
+
+	// Profile layered onto the stack defaults. Defaults to the appsody.dev/profile label of the namespace.
+	Profile string `json:"profile,omitempty"`
+
+	// Number of revisions of the resolved spec kept for rollbacks. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// Revision of status.revisions whose spec replaces the spec of the application. Cleared once restored.
+	RollbackTo *int64 `json:"rollbackTo,omitempty"`
+
+	// Applications which must be ready before the workload of the application is created or scaled up from 0
+	DependsOn []AppsodyApplicationDependency `json:"dependsOn,omitempty"`
+}
+
+// AppsodyApplicationDependency ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationDependency struct {
+	Name string `json:"name"`
+	// Defaults to the namespace of the application
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// WorkloadKind is the kind of the workload running the application. Defaults to KnativeService if
+// createKnativeService is set, to StatefulSet if storage is set and to Deployment otherwise.
+type WorkloadKind string
+
+const (
+	// WorkloadKindDeployment ...
+	WorkloadKindDeployment WorkloadKind = "Deployment"
+	// WorkloadKindStatefulSet ...
+	WorkloadKindStatefulSet WorkloadKind = "StatefulSet"
+	// WorkloadKindDaemonSet ...
+	WorkloadKindDaemonSet WorkloadKind = "DaemonSet"
+	// WorkloadKindCronJob ...
+	WorkloadKindCronJob WorkloadKind = "CronJob"
+	// WorkloadKindKnativeService ...
+	WorkloadKindKnativeService WorkloadKind = "KnativeService"
+)
+
+// AppsodyApplicationImagePolicy ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationImagePolicy struct {
+	// Interval between two checks for a new digest of the image, such as 2m or 1h. Defaults to 5m.
+	PollInterval string `json:"pollInterval,omitempty"`
+	// Tag of an OpenShift ImageStream in the namespace of the application, as <imagestream>:<tag>, resolved
+	// instead of the registry of the image when ImageStreams are available.
+	ImageStreamTag string `json:"imageStreamTag,omitempty"`
 }
 
 // AppsodyApplicationAutoScaling ...
@@ -51,18 +113,331 @@ type AppsodyApplicationService struct {
 	Port int32 `json:"port,omitempty"`
 }
 
+// AppsodyApplicationScheduling ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationScheduling struct {
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
+
+	// Spreads the replicas of the application across zones or nodes using preferred pod anti-affinity.
+	// +kubebuilder:validation:Enum=zone,node
+	SpreadReplicas SpreadReplicasMode `json:"spreadReplicas,omitempty"`
+}
+
+// SpreadReplicasMode ...
+type SpreadReplicasMode string
+
+const (
+	// SpreadReplicasZone ...
+	SpreadReplicasZone SpreadReplicasMode = "zone"
+	// SpreadReplicasNode ...
+	SpreadReplicasNode SpreadReplicasMode = "node"
+)
+
+// AppsodyApplicationSecurity ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationSecurity struct {
+	// Fills in the settings of the profile that are not set explicitly.
+	// +kubebuilder:validation:Enum=restricted
+	Profile        SecurityProfile                   `json:"profile,omitempty"`
+	Pod            *corev1.PodSecurityContext        `json:"pod,omitempty"`
+	Container      *corev1.SecurityContext           `json:"container,omitempty"`
+	SeccompProfile *AppsodyApplicationSeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// SecurityProfile ...
+type SecurityProfile string
+
+const (
+	// SecurityProfileRestricted ...
+	SecurityProfileRestricted SecurityProfile = "restricted"
+)
+
+// AppsodyApplicationSeccompProfile ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationSeccompProfile struct {
+	// +kubebuilder:validation:Enum=RuntimeDefault,Unconfined,Localhost
+	Type             SeccompProfileType `json:"type"`
+	LocalhostProfile *string            `json:"localhostProfile,omitempty"`
+}
+
+// SeccompProfileType ...
+type SeccompProfileType string
+
+const (
+	// SeccompProfileTypeRuntimeDefault ...
+	SeccompProfileTypeRuntimeDefault SeccompProfileType = "RuntimeDefault"
+	// SeccompProfileTypeUnconfined ...
+	SeccompProfileTypeUnconfined SeccompProfileType = "Unconfined"
+	// SeccompProfileTypeLocalhost ...
+	SeccompProfileTypeLocalhost SeccompProfileType = "Localhost"
+)
+
 // AppsodyApplicationStorage ...
 // +k8s:openapi-gen=true
 type AppsodyApplicationStorage struct {
 	Size                string                        `json:"size,omitempty"`
-	MountPath           string                        `json:"mountPath"`
+	MountPath           string                        `json:"mountPath,omitempty"`
 	VolumeClaimTemplate *corev1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	Volumes             []AppsodyApplicationVolume    `json:"volumes,omitempty"`
+}
+
+// AppsodyApplicationVolume ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationVolume struct {
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name             string                              `json:"name"`
+	Size             string                              `json:"size"`
+	MountPath        string                              `json:"mountPath"`
+	StorageClassName *string                             `json:"storageClassName,omitempty"`
+	AccessModes      []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	VolumeMode       *corev1.PersistentVolumeMode        `json:"volumeMode,omitempty"`
+}
+
+// AppsodyApplicationConfig ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationConfig struct {
+	Files []AppsodyApplicationConfigFile `json:"files,omitempty"`
+	Env   map[string]string              `json:"env,omitempty"`
+}
+
+// AppsodyApplicationConfigFile ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationConfigFile struct {
+	// Absolute path of the file inside the container.
+	// +kubebuilder:validation:Pattern=^/
+	Path            string                       `json:"path"`
+	Content         string                       `json:"content,omitempty"`
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+}
+
+// AppsodyApplicationHooks ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationHooks struct {
+	// Job run before the Deployment or StatefulSet is rolled out. The rollout waits for it to succeed.
+	PreDeploy *AppsodyApplicationHook `json:"preDeploy,omitempty"`
+	// Job run once the Deployment or StatefulSet has rolled out.
+	PostDeploy *AppsodyApplicationHook `json:"postDeploy,omitempty"`
+}
+
+// AppsodyApplicationHook ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationHook struct {
+	Command               []string        `json:"command,omitempty"`
+	Args                  []string        `json:"args,omitempty"`
+	Env                   []corev1.EnvVar `json:"env,omitempty"`
+	BackoffLimit          *int32          `json:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds *int64          `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// ConfigReference ...
+// +k8s:openapi-gen=true
+type ConfigReference struct {
+	// +kubebuilder:validation:Enum=ConfigMap,Secret
+	Kind ConfigReferenceKind `json:"kind"`
+	Name string              `json:"name"`
+}
+
+// ConfigReferenceKind ...
+type ConfigReferenceKind string
+
+const (
+	// ConfigReferenceKindConfigMap ...
+	ConfigReferenceKindConfigMap ConfigReferenceKind = "ConfigMap"
+	// ConfigReferenceKindSecret ...
+	ConfigReferenceKindSecret ConfigReferenceKind = "Secret"
+)
+
+// AppsodyApplicationKnative ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationKnative struct {
+	// Suffix appended to the application name to form the name of the generated revision.
+	// Defaults to a hash of the revision template so the name only changes with the template.
+	RevisionSuffix string                 `json:"revisionSuffix,omitempty"`
+	Traffic        []KnativeTrafficTarget `json:"traffic,omitempty"`
+}
+
+// KnativeTrafficTarget ...
+// +k8s:openapi-gen=true
+type KnativeTrafficTarget struct {
+	Tag            string `json:"tag,omitempty"`
+	RevisionName   string `json:"revisionName,omitempty"`
+	LatestRevision *bool  `json:"latestRevision,omitempty"`
+
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:validation:Minimum=0
+	Percent int `json:"percent"`
 }
 
 // AppsodyApplicationStatus defines the observed state of AppsodyApplication
 // +k8s:openapi-gen=true
 type AppsodyApplicationStatus struct {
 	Conditions []StatusCondition `json:"conditions,omitempty"`
+	Knative    *KnativeStatus    `json:"knative,omitempty"`
+	Volumes    []VolumeStatus    `json:"volumes,omitempty"`
+
+	// Generation of the spec the Reconciled condition reports on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Hash of the contents of the ConfigMaps and Secrets referenced by the application
+	ConfigHash string `json:"configHash,omitempty"`
+
+	Hooks []HookStatus `json:"hooks,omitempty"`
+
+	// Fields of the application which differ from the constants of its stack
+	PolicyViolations []PolicyViolation `json:"policyViolations,omitempty"`
+
+	// Digests deployed by the image policy, most recent last
+	ImageDigests []ImageDigestStatus `json:"imageDigests,omitempty"`
+
+	// Architectures supported by the image, read from its registry
+	ImageArchitectures []string `json:"imageArchitectures,omitempty"`
+
+	// Profile whose overrides are applied to the stack defaults
+	Profile string `json:"profile,omitempty"`
+
+	// Revisions of the resolved spec kept for rollbacks, most recent last
+	Revisions []RevisionStatus `json:"revisions,omitempty"`
+}
+
+// RevisionStatus ...
+// +k8s:openapi-gen=true
+type RevisionStatus struct {
+	Revision int64 `json:"revision"`
+	// Name of the ControllerRevision holding the spec
+	Name string `json:"name"`
+	// Image deployed by the revision, pinned by digest when the image policy resolved it
+	Image     string      `json:"image"`
+	CreatedAt metav1.Time `json:"createdAt"`
+}
+
+// ImageDigestStatus ...
+// +k8s:openapi-gen=true
+type ImageDigestStatus struct {
+	// Image of the application the digest was resolved from
+	Image string `json:"image"`
+	// Image pinned by digest, deployed in place of the image of the application
+	PinnedImage string      `json:"pinnedImage"`
+	Digest      string      `json:"digest"`
+	DeployedAt  metav1.Time `json:"deployedAt"`
+}
+
+// PolicyViolation ...
+// +k8s:openapi-gen=true
+type PolicyViolation struct {
+	Field string     `json:"field"`
+	Mode  PolicyMode `json:"mode"`
+	// Value of the application, JSON encoded
+	Value string `json:"value,omitempty"`
+	// Value of the constant, JSON encoded, or the constraint which is not met
+	Constant string `json:"constant,omitempty"`
+	// Action taken for a constraint when the policy is enforced
+	Action ConstraintAction `json:"action,omitempty"`
+}
+
+// PolicyMode defines how the constants of a stack are applied to its applications
+type PolicyMode string
+
+const (
+	// PolicyModeEnforce replaces the values of the application with the constants
+	PolicyModeEnforce PolicyMode = "enforce"
+	// PolicyModeWarn keeps the values of the application and emits a warning for each violation
+	PolicyModeWarn PolicyMode = "warn"
+	// PolicyModeAudit keeps the values of the application and only records violations in the status
+	PolicyModeAudit PolicyMode = "audit"
+)
+
+// ConstraintAction defines what happens when an application does not meet a constraint of its stack
+type ConstraintAction string
+
+const (
+	// ConstraintActionReject stops reconciling the application until it meets the constraint
+	ConstraintActionReject ConstraintAction = "reject"
+	// ConstraintActionClamp replaces the value of the application with the closest bound
+	ConstraintActionClamp ConstraintAction = "clamp"
+)
+
+// HookStatus ...
+// +k8s:openapi-gen=true
+type HookStatus struct {
+	Name           HookName     `json:"name"`
+	JobName        string       `json:"jobName,omitempty"`
+	Phase          HookPhase    `json:"phase,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Number of failed attempts, retried up to backoffLimit times
+	FailedAttempts int32  `json:"failedAttempts,omitempty"`
+	BackoffLimit   *int32 `json:"backoffLimit,omitempty"`
+	// Label selector of the pods of the Job, to read the logs of the hook
+	LogsSelector string `json:"logsSelector,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// HookName ...
+type HookName string
+
+const (
+	// HookNamePreDeploy ...
+	HookNamePreDeploy HookName = "preDeploy"
+	// HookNamePostDeploy ...
+	HookNamePostDeploy HookName = "postDeploy"
+)
+
+// HookPhase ...
+type HookPhase string
+
+const (
+	// HookPhaseWaiting ...
+	HookPhaseWaiting HookPhase = "Waiting"
+	// HookPhaseRunning ...
+	HookPhaseRunning HookPhase = "Running"
+	// HookPhaseSucceeded ...
+	HookPhaseSucceeded HookPhase = "Succeeded"
+	// HookPhaseFailed ...
+	HookPhaseFailed HookPhase = "Failed"
+)
+
+// VolumeStatus ...
+// +k8s:openapi-gen=true
+type VolumeStatus struct {
+	Name          string            `json:"name"`
+	RequestedSize string            `json:"requestedSize,omitempty"`
+	Capacity      string            `json:"capacity,omitempty"`
+	Phase         VolumeResizePhase `json:"phase,omitempty"`
+}
+
+// VolumeResizePhase ...
+type VolumeResizePhase string
+
+const (
+	// VolumeResizePhasePending ...
+	VolumeResizePhasePending VolumeResizePhase = "Pending"
+	// VolumeResizePhaseResizing ...
+	VolumeResizePhaseResizing VolumeResizePhase = "Resizing"
+	// VolumeResizePhaseFileSystemResizePending ...
+	VolumeResizePhaseFileSystemResizePending VolumeResizePhase = "FileSystemResizePending"
+	// VolumeResizePhaseResized ...
+	VolumeResizePhaseResized VolumeResizePhase = "Resized"
+)
+
+// KnativeStatus ...
+// +k8s:openapi-gen=true
+type KnativeStatus struct {
+	URL                       string                 `json:"url,omitempty"`
+	LatestCreatedRevisionName string                 `json:"latestCreatedRevisionName,omitempty"`
+	LatestReadyRevisionName   string                 `json:"latestReadyRevisionName,omitempty"`
+	Traffic                   []KnativeTrafficStatus `json:"traffic,omitempty"`
+}
+
+// KnativeTrafficStatus ...
+// +k8s:openapi-gen=true
+type KnativeTrafficStatus struct {
+	Tag          string `json:"tag,omitempty"`
+	RevisionName string `json:"revisionName,omitempty"`
+	Percent      int    `json:"percent,omitempty"`
+	URL          string `json:"url,omitempty"`
 }
 
 // StatusCondition ...
@@ -82,6 +457,14 @@ type StatusConditionType string
 const (
 	// StatusConditionTypeReconciled ...
 	StatusConditionTypeReconciled StatusConditionType = "Reconciled"
+	// StatusConditionTypeVolumeExpansion ...
+	StatusConditionTypeVolumeExpansion StatusConditionType = "VolumeExpansion"
+	// StatusConditionTypeStackRecognized ...
+	StatusConditionTypeStackRecognized StatusConditionType = "StackRecognized"
+	// StatusConditionTypeArchitectureSupported ...
+	StatusConditionTypeArchitectureSupported StatusConditionType = "ArchitectureSupported"
+	// StatusConditionTypeWaitingForDependencies ...
+	StatusConditionTypeWaitingForDependencies StatusConditionType = "WaitingForDependencies"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -91,6 +474,7 @@ const (
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.applicationImage",priority="0",description="Absolute name of the deployed image containing registry and tag"
 // +kubebuilder:printcolumn:name="Exposed",type="boolean",JSONPath=".spec.expose",priority="0",description="Specifies whether deployment is exposed externally via default Route"
+// +kubebuilder:printcolumn:name="Profile",type="string",JSONPath=".status.profile",priority="1",description="Profile applied to the stack defaults"
 // +kubebuilder:printcolumn:name="Reconciled",type="string",JSONPath=".status.conditions[?(@.type=='Reconciled')].status",priority="0",description="Status of the reconcile condition"
 // +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Reconciled')].reason",priority="1",description="Reason for the failure of reconcile condition"
 // +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[?(@.type=='Reconciled')].message",priority="1",description="Failure message from reconcile condition"
@@ -0,0 +1,118 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppsodyApplicationSetSpec defines the desired state of AppsodyApplicationSet
+// +k8s:openapi-gen=true
+type AppsodyApplicationSetSpec struct {
+	Template AppsodyApplicationSetTemplate `json:"template"`
+	// Each generator produces the parameters of applications, which are rendered from the template
+	Generators []AppsodyApplicationSetGenerator `json:"generators"`
+	Rollout    *AppsodyApplicationSetRollout    `json:"rollout,omitempty"`
+}
+
+// AppsodyApplicationSetTemplate holds the applications of the set. The {{parameter}} placeholders of its strings are
+// replaced by the parameters of each application.
+// +k8s:openapi-gen=true
+type AppsodyApplicationSetTemplate struct {
+	Metadata AppsodyApplicationSetTemplateMeta `json:"metadata"`
+	Spec     AppsodyApplicationSpec            `json:"spec"`
+}
+
+// AppsodyApplicationSetTemplateMeta ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationSetTemplateMeta struct {
+	Name string `json:"name"`
+	// Defaults to the namespace of the set
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AppsodyApplicationSetGenerator sets one of list, namespaces or configMap
+// +k8s:openapi-gen=true
+type AppsodyApplicationSetGenerator struct {
+	// Generates an application for each element
+	List []ApplicationSetListElement `json:"list,omitempty"`
+	// Generates an application for each namespace matching the selector, with the namespace parameter
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	// Generates an application for each key of a ConfigMap in the namespace of the set, with the key parameter
+	// and the parameters of the JSON object of its value
+	ConfigMap *ApplicationSetConfigMapGenerator `json:"configMap,omitempty"`
+}
+
+// ApplicationSetListElement ...
+// +k8s:openapi-gen=true
+type ApplicationSetListElement struct {
+	Parameters map[string]string `json:"parameters"`
+}
+
+// ApplicationSetConfigMapGenerator ...
+// +k8s:openapi-gen=true
+type ApplicationSetConfigMapGenerator struct {
+	Name string `json:"name"`
+}
+
+// AppsodyApplicationSetRollout ...
+// +k8s:openapi-gen=true
+type AppsodyApplicationSetRollout struct {
+	// Number of applications which may be not ready while the template is rolled out. The next applications are
+	// updated once the previous ones are ready. Defaults to all the applications.
+	// +kubebuilder:validation:Minimum=1
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+}
+
+// AppsodyApplicationSetStatus defines the observed state of AppsodyApplicationSet
+// +k8s:openapi-gen=true
+type AppsodyApplicationSetStatus struct {
+	Applications        int32 `json:"applications"`
+	ReadyApplications   int32 `json:"readyApplications"`
+	UpdatedApplications int32 `json:"updatedApplications"`
+
+	// Applications of the set, in the order of the generators
+	Children []ApplicationSetChildStatus `json:"children,omitempty"`
+	// Error preventing the set from being reconciled
+	Message string `json:"message,omitempty"`
+}
+
+// ApplicationSetChildStatus ...
+// +k8s:openapi-gen=true
+type ApplicationSetChildStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Ready     bool   `json:"ready"`
+	// Whether the application matches the current template
+	Updated bool `json:"updated"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppsodyApplicationSet is the Schema for the appsodyapplicationsets API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Applications",type="integer",JSONPath=".status.applications",priority="0",description="Number of applications of the set"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyApplications",priority="0",description="Number of ready applications"
+// +kubebuilder:printcolumn:name="Updated",type="integer",JSONPath=".status.updatedApplications",priority="0",description="Number of applications matching the template"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",priority="0",description="Age of the resource"
+type AppsodyApplicationSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AppsodyApplicationSetSpec   `json:"spec,omitempty"`
+	Status AppsodyApplicationSetStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppsodyApplicationSetList contains a list of AppsodyApplicationSet
+type AppsodyApplicationSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppsodyApplicationSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppsodyApplicationSet{}, &AppsodyApplicationSetList{})
+}
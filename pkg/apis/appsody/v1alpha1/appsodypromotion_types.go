@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppsodyPromotionSpec defines the desired state of AppsodyPromotion
+// +k8s:openapi-gen=true
+type AppsodyPromotionSpec struct {
+	Source PromotionReference `json:"source"`
+	Target PromotionReference `json:"target"`
+
+	// Fields of the source spec copied to the target along with the image, such as env or resourceConstraints
+	Fields []string `json:"fields,omitempty"`
+	// Waits for the appsody.dev/approved annotation before promoting an image
+	RequireApproval bool `json:"requireApproval,omitempty"`
+}
+
+// PromotionReference ...
+// +k8s:openapi-gen=true
+type PromotionReference struct {
+	Name string `json:"name"`
+	// Defaults to the namespace of the promotion
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AppsodyPromotionStatus defines the observed state of AppsodyPromotion
+// +k8s:openapi-gen=true
+type AppsodyPromotionStatus struct {
+	Phase   PromotionPhase `json:"phase,omitempty"`
+	Message string         `json:"message,omitempty"`
+	// Image of the source pinned by digest, waiting to be promoted or last promoted
+	Image string `json:"image,omitempty"`
+
+	// Promotions to the target, most recent last
+	History []PromotionRecord `json:"history,omitempty"`
+}
+
+// PromotionRecord ...
+// +k8s:openapi-gen=true
+type PromotionRecord struct {
+	Image  string   `json:"image"`
+	Fields []string `json:"fields,omitempty"`
+	// Value of the approval annotation when the image was promoted
+	Approval   string      `json:"approval,omitempty"`
+	PromotedAt metav1.Time `json:"promotedAt"`
+}
+
+// PromotionPhase ...
+type PromotionPhase string
+
+const (
+	// PromotionPhaseWaitingForSource ...
+	PromotionPhaseWaitingForSource PromotionPhase = "WaitingForSource"
+	// PromotionPhaseWaitingForApproval ...
+	PromotionPhaseWaitingForApproval PromotionPhase = "WaitingForApproval"
+	// PromotionPhasePromoted ...
+	PromotionPhasePromoted PromotionPhase = "Promoted"
+	// PromotionPhaseFailed ...
+	PromotionPhaseFailed PromotionPhase = "Failed"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppsodyPromotion is the Schema for the appsodypromotions API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Source",type="string",JSONPath=".spec.source.name",priority="0",description="Application the image is promoted from"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.target.name",priority="0",description="Application the image is promoted to"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",priority="0",description="Phase of the promotion"
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.image",priority="1",description="Image of the source pinned by digest"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",priority="0",description="Age of the resource"
+type AppsodyPromotion struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AppsodyPromotionSpec   `json:"spec,omitempty"`
+	Status AppsodyPromotionStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppsodyPromotionList contains a list of AppsodyPromotion
+type AppsodyPromotionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppsodyPromotion `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppsodyPromotion{}, &AppsodyPromotionList{})
+}
@@ -6,9 +6,65 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetChildStatus) DeepCopyInto(out *ApplicationSetChildStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetChildStatus.
+func (in *ApplicationSetChildStatus) DeepCopy() *ApplicationSetChildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetChildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetConfigMapGenerator) DeepCopyInto(out *ApplicationSetConfigMapGenerator) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetConfigMapGenerator.
+func (in *ApplicationSetConfigMapGenerator) DeepCopy() *ApplicationSetConfigMapGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetConfigMapGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetListElement) DeepCopyInto(out *ApplicationSetListElement) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetListElement.
+func (in *ApplicationSetListElement) DeepCopy() *ApplicationSetListElement {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetListElement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppsodyApplication) DeepCopyInto(out *AppsodyApplication) {
 	*out = *in
@@ -64,174 +120,193 @@ func (in *AppsodyApplicationAutoScaling) DeepCopy() *AppsodyApplicationAutoScali
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AppsodyApplicationList) DeepCopyInto(out *AppsodyApplicationList) {
+func (in *AppsodyApplicationConfig) DeepCopyInto(out *AppsodyApplicationConfig) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]AppsodyApplication, len(*in))
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]AppsodyApplicationConfigFile, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationList.
-func (in *AppsodyApplicationList) DeepCopy() *AppsodyApplicationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationConfig.
+func (in *AppsodyApplicationConfig) DeepCopy() *AppsodyApplicationConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(AppsodyApplicationList)
+	out := new(AppsodyApplicationConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *AppsodyApplicationList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationConfigFile) DeepCopyInto(out *AppsodyApplicationConfigFile) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
 	}
-	return nil
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationConfigFile.
+func (in *AppsodyApplicationConfigFile) DeepCopy() *AppsodyApplicationConfigFile {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationConfigFile)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AppsodyApplicationService) DeepCopyInto(out *AppsodyApplicationService) {
+func (in *AppsodyApplicationDependency) DeepCopyInto(out *AppsodyApplicationDependency) {
 	*out = *in
-	if in.Type != nil {
-		in, out := &in.Type, &out.Type
-		*out = new(v1.ServiceType)
-		**out = **in
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationService.
-func (in *AppsodyApplicationService) DeepCopy() *AppsodyApplicationService {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationDependency.
+func (in *AppsodyApplicationDependency) DeepCopy() *AppsodyApplicationDependency {
 	if in == nil {
 		return nil
 	}
-	out := new(AppsodyApplicationService)
+	out := new(AppsodyApplicationDependency)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AppsodyApplicationSpec) DeepCopyInto(out *AppsodyApplicationSpec) {
+func (in *AppsodyApplicationHook) DeepCopyInto(out *AppsodyApplicationHook) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
-	}
-	if in.Autoscaling != nil {
-		in, out := &in.Autoscaling, &out.Autoscaling
-		*out = new(AppsodyApplicationAutoScaling)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.PullPolicy != nil {
-		in, out := &in.PullPolicy, &out.PullPolicy
-		*out = new(v1.PullPolicy)
-		**out = **in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.PullSecret != nil {
-		in, out := &in.PullSecret, &out.PullSecret
-		*out = new(string)
-		**out = **in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.Volumes != nil {
-		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.VolumeMounts != nil {
-		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
 	}
-	if in.ResourceConstraints != nil {
-		in, out := &in.ResourceConstraints, &out.ResourceConstraints
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
 	}
-	if in.ReadinessProbe != nil {
-		in, out := &in.ReadinessProbe, &out.ReadinessProbe
-		*out = new(v1.Probe)
-		(*in).DeepCopyInto(*out)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationHook.
+func (in *AppsodyApplicationHook) DeepCopy() *AppsodyApplicationHook {
+	if in == nil {
+		return nil
 	}
-	if in.LivenessProbe != nil {
-		in, out := &in.LivenessProbe, &out.LivenessProbe
-		*out = new(v1.Probe)
+	out := new(AppsodyApplicationHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationHooks) DeepCopyInto(out *AppsodyApplicationHooks) {
+	*out = *in
+	if in.PreDeploy != nil {
+		in, out := &in.PreDeploy, &out.PreDeploy
+		*out = new(AppsodyApplicationHook)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.Service != nil {
-		in, out := &in.Service, &out.Service
-		*out = new(AppsodyApplicationService)
+	if in.PostDeploy != nil {
+		in, out := &in.PostDeploy, &out.PostDeploy
+		*out = new(AppsodyApplicationHook)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.Expose != nil {
-		in, out := &in.Expose, &out.Expose
-		*out = new(bool)
-		**out = **in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationHooks.
+func (in *AppsodyApplicationHooks) DeepCopy() *AppsodyApplicationHooks {
+	if in == nil {
+		return nil
 	}
-	if in.EnvFrom != nil {
-		in, out := &in.EnvFrom, &out.EnvFrom
-		*out = make([]v1.EnvFromSource, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out := new(AppsodyApplicationHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationImagePolicy) DeepCopyInto(out *AppsodyApplicationImagePolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationImagePolicy.
+func (in *AppsodyApplicationImagePolicy) DeepCopy() *AppsodyApplicationImagePolicy {
+	if in == nil {
+		return nil
 	}
-	if in.Env != nil {
-		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+	out := new(AppsodyApplicationImagePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationKnative) DeepCopyInto(out *AppsodyApplicationKnative) {
+	*out = *in
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = make([]KnativeTrafficTarget, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.ServiceAccountName != nil {
-		in, out := &in.ServiceAccountName, &out.ServiceAccountName
-		*out = new(string)
-		**out = **in
-	}
-	if in.Architecture != nil {
-		in, out := &in.Architecture, &out.Architecture
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = new(AppsodyApplicationStorage)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.CreateKnativeService != nil {
-		in, out := &in.CreateKnativeService, &out.CreateKnativeService
-		*out = new(bool)
-		**out = **in
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSpec.
-func (in *AppsodyApplicationSpec) DeepCopy() *AppsodyApplicationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationKnative.
+func (in *AppsodyApplicationKnative) DeepCopy() *AppsodyApplicationKnative {
 	if in == nil {
 		return nil
 	}
-	out := new(AppsodyApplicationSpec)
+	out := new(AppsodyApplicationKnative)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AppsodyApplicationStatus) DeepCopyInto(out *AppsodyApplicationStatus) {
+func (in *AppsodyApplicationList) DeepCopyInto(out *AppsodyApplicationList) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]StatusCondition, len(*in))
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppsodyApplication, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -239,54 +314,963 @@ func (in *AppsodyApplicationStatus) DeepCopyInto(out *AppsodyApplicationStatus)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationStatus.
-func (in *AppsodyApplicationStatus) DeepCopy() *AppsodyApplicationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationList.
+func (in *AppsodyApplicationList) DeepCopy() *AppsodyApplicationList {
 	if in == nil {
 		return nil
 	}
-	out := new(AppsodyApplicationStatus)
+	out := new(AppsodyApplicationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppsodyApplicationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AppsodyApplicationStorage) DeepCopyInto(out *AppsodyApplicationStorage) {
+func (in *AppsodyApplicationScheduling) DeepCopyInto(out *AppsodyApplicationScheduling) {
 	*out = *in
-	if in.VolumeClaimTemplate != nil {
-		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
-		*out = new(v1.PersistentVolumeClaim)
-		(*in).DeepCopyInto(*out)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationStorage.
-func (in *AppsodyApplicationStorage) DeepCopy() *AppsodyApplicationStorage {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationScheduling.
+func (in *AppsodyApplicationScheduling) DeepCopy() *AppsodyApplicationScheduling {
 	if in == nil {
 		return nil
 	}
-	out := new(AppsodyApplicationStorage)
+	out := new(AppsodyApplicationScheduling)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *StatusCondition) DeepCopyInto(out *StatusCondition) {
+func (in *AppsodyApplicationSeccompProfile) DeepCopyInto(out *AppsodyApplicationSeccompProfile) {
 	*out = *in
-	if in.LastTransitionTime != nil {
-		in, out := &in.LastTransitionTime, &out.LastTransitionTime
-		*out = (*in).DeepCopy()
+	if in.LocalhostProfile != nil {
+		in, out := &in.LocalhostProfile, &out.LocalhostProfile
+		*out = new(string)
+		**out = **in
 	}
-	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusCondition.
-func (in *StatusCondition) DeepCopy() *StatusCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSeccompProfile.
+func (in *AppsodyApplicationSeccompProfile) DeepCopy() *AppsodyApplicationSeccompProfile {
 	if in == nil {
 		return nil
 	}
-	out := new(StatusCondition)
+	out := new(AppsodyApplicationSeccompProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSecurity) DeepCopyInto(out *AppsodyApplicationSecurity) {
+	*out = *in
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SeccompProfile != nil {
+		in, out := &in.SeccompProfile, &out.SeccompProfile
+		*out = new(AppsodyApplicationSeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSecurity.
+func (in *AppsodyApplicationSecurity) DeepCopy() *AppsodyApplicationSecurity {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSecurity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationService) DeepCopyInto(out *AppsodyApplicationService) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(v1.ServiceType)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationService.
+func (in *AppsodyApplicationService) DeepCopy() *AppsodyApplicationService {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSet) DeepCopyInto(out *AppsodyApplicationSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSet.
+func (in *AppsodyApplicationSet) DeepCopy() *AppsodyApplicationSet {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppsodyApplicationSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetGenerator) DeepCopyInto(out *AppsodyApplicationSetGenerator) {
+	*out = *in
+	if in.List != nil {
+		in, out := &in.List, &out.List
+		*out = make([]ApplicationSetListElement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ApplicationSetConfigMapGenerator)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetGenerator.
+func (in *AppsodyApplicationSetGenerator) DeepCopy() *AppsodyApplicationSetGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetList) DeepCopyInto(out *AppsodyApplicationSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppsodyApplicationSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetList.
+func (in *AppsodyApplicationSetList) DeepCopy() *AppsodyApplicationSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppsodyApplicationSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetRollout) DeepCopyInto(out *AppsodyApplicationSetRollout) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetRollout.
+func (in *AppsodyApplicationSetRollout) DeepCopy() *AppsodyApplicationSetRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetSpec) DeepCopyInto(out *AppsodyApplicationSetSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Generators != nil {
+		in, out := &in.Generators, &out.Generators
+		*out = make([]AppsodyApplicationSetGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(AppsodyApplicationSetRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetSpec.
+func (in *AppsodyApplicationSetSpec) DeepCopy() *AppsodyApplicationSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetStatus) DeepCopyInto(out *AppsodyApplicationSetStatus) {
+	*out = *in
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]ApplicationSetChildStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetStatus.
+func (in *AppsodyApplicationSetStatus) DeepCopy() *AppsodyApplicationSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetTemplate) DeepCopyInto(out *AppsodyApplicationSetTemplate) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetTemplate.
+func (in *AppsodyApplicationSetTemplate) DeepCopy() *AppsodyApplicationSetTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSetTemplateMeta) DeepCopyInto(out *AppsodyApplicationSetTemplateMeta) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSetTemplateMeta.
+func (in *AppsodyApplicationSetTemplateMeta) DeepCopy() *AppsodyApplicationSetTemplateMeta {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSetTemplateMeta)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationSpec) DeepCopyInto(out *AppsodyApplicationSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(AppsodyApplicationAutoScaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PullPolicy != nil {
+		in, out := &in.PullPolicy, &out.PullPolicy
+		*out = new(v1.PullPolicy)
+		**out = **in
+	}
+	if in.PullSecret != nil {
+		in, out := &in.PullSecret, &out.PullSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.PullSecrets != nil {
+		in, out := &in.PullSecrets, &out.PullSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceConstraints != nil {
+		in, out := &in.ResourceConstraints, &out.ResourceConstraints
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(AppsodyApplicationService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]v1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RolloutExclusions != nil {
+		in, out := &in.RolloutExclusions, &out.RolloutExclusions
+		*out = make([]ConfigReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(AppsodyApplicationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(AppsodyApplicationHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
+	if in.PatchServiceAccount != nil {
+		in, out := &in.PatchServiceAccount, &out.PatchServiceAccount
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Architecture != nil {
+		in, out := &in.Architecture, &out.Architecture
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Scheduling != nil {
+		in, out := &in.Scheduling, &out.Scheduling
+		*out = new(AppsodyApplicationScheduling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(AppsodyApplicationSecurity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(AppsodyApplicationStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CreateKnativeService != nil {
+		in, out := &in.CreateKnativeService, &out.CreateKnativeService
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Knative != nil {
+		in, out := &in.Knative, &out.Knative
+		*out = new(AppsodyApplicationKnative)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePolicy != nil {
+		in, out := &in.ImagePolicy, &out.ImagePolicy
+		*out = new(AppsodyApplicationImagePolicy)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RollbackTo != nil {
+		in, out := &in.RollbackTo, &out.RollbackTo
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]AppsodyApplicationDependency, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationSpec.
+func (in *AppsodyApplicationSpec) DeepCopy() *AppsodyApplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationStatus) DeepCopyInto(out *AppsodyApplicationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]StatusCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Knative != nil {
+		in, out := &in.Knative, &out.Knative
+		*out = new(KnativeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]VolumeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]HookStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PolicyViolations != nil {
+		in, out := &in.PolicyViolations, &out.PolicyViolations
+		*out = make([]PolicyViolation, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageDigests != nil {
+		in, out := &in.ImageDigests, &out.ImageDigests
+		*out = make([]ImageDigestStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImageArchitectures != nil {
+		in, out := &in.ImageArchitectures, &out.ImageArchitectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]RevisionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationStatus.
+func (in *AppsodyApplicationStatus) DeepCopy() *AppsodyApplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationStorage) DeepCopyInto(out *AppsodyApplicationStorage) {
+	*out = *in
+	if in.VolumeClaimTemplate != nil {
+		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
+		*out = new(v1.PersistentVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]AppsodyApplicationVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationStorage.
+func (in *AppsodyApplicationStorage) DeepCopy() *AppsodyApplicationStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyApplicationVolume) DeepCopyInto(out *AppsodyApplicationVolume) {
+	*out = *in
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(v1.PersistentVolumeMode)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyApplicationVolume.
+func (in *AppsodyApplicationVolume) DeepCopy() *AppsodyApplicationVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyApplicationVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyPromotion) DeepCopyInto(out *AppsodyPromotion) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyPromotion.
+func (in *AppsodyPromotion) DeepCopy() *AppsodyPromotion {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyPromotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppsodyPromotion) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyPromotionList) DeepCopyInto(out *AppsodyPromotionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppsodyPromotion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyPromotionList.
+func (in *AppsodyPromotionList) DeepCopy() *AppsodyPromotionList {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyPromotionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppsodyPromotionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyPromotionSpec) DeepCopyInto(out *AppsodyPromotionSpec) {
+	*out = *in
+	out.Source = in.Source
+	out.Target = in.Target
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyPromotionSpec.
+func (in *AppsodyPromotionSpec) DeepCopy() *AppsodyPromotionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyPromotionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppsodyPromotionStatus) DeepCopyInto(out *AppsodyPromotionStatus) {
+	*out = *in
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]PromotionRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppsodyPromotionStatus.
+func (in *AppsodyPromotionStatus) DeepCopy() *AppsodyPromotionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AppsodyPromotionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigReference) DeepCopyInto(out *ConfigReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigReference.
+func (in *ConfigReference) DeepCopy() *ConfigReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookStatus) DeepCopyInto(out *HookStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookStatus.
+func (in *HookStatus) DeepCopy() *HookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageDigestStatus) DeepCopyInto(out *ImageDigestStatus) {
+	*out = *in
+	in.DeployedAt.DeepCopyInto(&out.DeployedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageDigestStatus.
+func (in *ImageDigestStatus) DeepCopy() *ImageDigestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageDigestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeStatus) DeepCopyInto(out *KnativeStatus) {
+	*out = *in
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = make([]KnativeTrafficStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeStatus.
+func (in *KnativeStatus) DeepCopy() *KnativeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeTrafficStatus) DeepCopyInto(out *KnativeTrafficStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeTrafficStatus.
+func (in *KnativeTrafficStatus) DeepCopy() *KnativeTrafficStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeTrafficStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeTrafficTarget) DeepCopyInto(out *KnativeTrafficTarget) {
+	*out = *in
+	if in.LatestRevision != nil {
+		in, out := &in.LatestRevision, &out.LatestRevision
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeTrafficTarget.
+func (in *KnativeTrafficTarget) DeepCopy() *KnativeTrafficTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeTrafficTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyViolation) DeepCopyInto(out *PolicyViolation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyViolation.
+func (in *PolicyViolation) DeepCopy() *PolicyViolation {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyViolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionRecord) DeepCopyInto(out *PromotionRecord) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.PromotedAt.DeepCopyInto(&out.PromotedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionRecord.
+func (in *PromotionRecord) DeepCopy() *PromotionRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionReference) DeepCopyInto(out *PromotionReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionReference.
+func (in *PromotionReference) DeepCopy() *PromotionReference {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionStatus) DeepCopyInto(out *RevisionStatus) {
+	*out = *in
+	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionStatus.
+func (in *RevisionStatus) DeepCopy() *RevisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusCondition) DeepCopyInto(out *StatusCondition) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusCondition.
+func (in *StatusCondition) DeepCopy() *StatusCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeStatus) DeepCopyInto(out *VolumeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeStatus.
+func (in *VolumeStatus) DeepCopy() *VolumeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -11,13 +11,134 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"./pkg/apis/appsody/v1alpha1.AppsodyApplication":            schema_pkg_apis_appsody_v1alpha1_AppsodyApplication(ref),
-		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationAutoScaling": schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationAutoScaling(ref),
-		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationService":     schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationService(ref),
-		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec":        schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSpec(ref),
-		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationStatus":      schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStatus(ref),
-		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationStorage":     schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStorage(ref),
-		"./pkg/apis/appsody/v1alpha1.StatusCondition":               schema_pkg_apis_appsody_v1alpha1_StatusCondition(ref),
+		"./pkg/apis/appsody/v1alpha1.ApplicationSetChildStatus":         schema_pkg_apis_appsody_v1alpha1_ApplicationSetChildStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.ApplicationSetConfigMapGenerator":  schema_pkg_apis_appsody_v1alpha1_ApplicationSetConfigMapGenerator(ref),
+		"./pkg/apis/appsody/v1alpha1.ApplicationSetListElement":         schema_pkg_apis_appsody_v1alpha1_ApplicationSetListElement(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplication":                schema_pkg_apis_appsody_v1alpha1_AppsodyApplication(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationAutoScaling":     schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationAutoScaling(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationConfig":          schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationConfig(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationConfigFile":      schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationConfigFile(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationDependency":      schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationDependency(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationHook":            schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationHook(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationHooks":           schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationHooks(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationImagePolicy":     schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationImagePolicy(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationKnative":         schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationKnative(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationScheduling":      schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationScheduling(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSeccompProfile":  schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSeccompProfile(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSecurity":        schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSecurity(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationService":         schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationService(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSet":             schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSet(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetGenerator":    schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetGenerator(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetRollout":      schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetRollout(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetSpec":         schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetSpec(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetStatus":       schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetTemplate":     schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetTemplate(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetTemplateMeta": schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetTemplateMeta(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec":            schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSpec(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationStatus":          schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationStorage":         schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStorage(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyApplicationVolume":          schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationVolume(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyPromotion":                  schema_pkg_apis_appsody_v1alpha1_AppsodyPromotion(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyPromotionSpec":              schema_pkg_apis_appsody_v1alpha1_AppsodyPromotionSpec(ref),
+		"./pkg/apis/appsody/v1alpha1.AppsodyPromotionStatus":            schema_pkg_apis_appsody_v1alpha1_AppsodyPromotionStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.ConfigReference":                   schema_pkg_apis_appsody_v1alpha1_ConfigReference(ref),
+		"./pkg/apis/appsody/v1alpha1.HookStatus":                        schema_pkg_apis_appsody_v1alpha1_HookStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.ImageDigestStatus":                 schema_pkg_apis_appsody_v1alpha1_ImageDigestStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.KnativeStatus":                     schema_pkg_apis_appsody_v1alpha1_KnativeStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.KnativeTrafficStatus":              schema_pkg_apis_appsody_v1alpha1_KnativeTrafficStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.KnativeTrafficTarget":              schema_pkg_apis_appsody_v1alpha1_KnativeTrafficTarget(ref),
+		"./pkg/apis/appsody/v1alpha1.PolicyViolation":                   schema_pkg_apis_appsody_v1alpha1_PolicyViolation(ref),
+		"./pkg/apis/appsody/v1alpha1.PromotionRecord":                   schema_pkg_apis_appsody_v1alpha1_PromotionRecord(ref),
+		"./pkg/apis/appsody/v1alpha1.PromotionReference":                schema_pkg_apis_appsody_v1alpha1_PromotionReference(ref),
+		"./pkg/apis/appsody/v1alpha1.RevisionStatus":                    schema_pkg_apis_appsody_v1alpha1_RevisionStatus(ref),
+		"./pkg/apis/appsody/v1alpha1.StatusCondition":                   schema_pkg_apis_appsody_v1alpha1_StatusCondition(ref),
+		"./pkg/apis/appsody/v1alpha1.VolumeStatus":                      schema_pkg_apis_appsody_v1alpha1_VolumeStatus(ref),
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_ApplicationSetChildStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApplicationSetChildStatus ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"ready": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"updated": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether the application matches the current template",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "namespace", "ready", "updated"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_ApplicationSetConfigMapGenerator(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApplicationSetConfigMapGenerator ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_ApplicationSetListElement(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ApplicationSetListElement ...",
+				Properties: map[string]spec.Schema{
+					"parameters": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"parameters"},
+			},
+		},
+		Dependencies: []string{},
 	}
 }
 
@@ -29,90 +150,1571 @@ func schema_pkg_apis_appsody_v1alpha1_AppsodyApplication(ref common.ReferenceCal
 				Properties: map[string]spec.Schema{
 					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationAutoScaling(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationAutoScaling ...",
+				Properties: map[string]spec.Schema{
+					"targetCPUUtilizationPercentage": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"minReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"maxReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationConfig ...",
+				Properties: map[string]spec.Schema{
+					"files": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationConfigFile"),
+									},
+								},
+							},
+						},
+					},
+					"env": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationConfigFile"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationConfigFile(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationConfigFile ...",
+				Properties: map[string]spec.Schema{
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Absolute path of the file inside the container.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"content": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"configMapKeyRef": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.ConfigMapKeySelector"),
+						},
+					},
+					"secretKeyRef": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.SecretKeySelector"),
+						},
+					},
+				},
+				Required: []string{"path"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.ConfigMapKeySelector", "k8s.io/api/core/v1.SecretKeySelector"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationDependency(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationDependency ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Defaults to the namespace of the application",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationHook ...",
+				Properties: map[string]spec.Schema{
+					"command": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"args": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"env": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"backoffLimit": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"activeDeadlineSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int64",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.EnvVar"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationHooks(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationHooks ...",
+				Properties: map[string]spec.Schema{
+					"preDeploy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Job run before the Deployment or StatefulSet is rolled out. The rollout waits for it to succeed.",
+							Ref:         ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationHook"),
+						},
+					},
+					"postDeploy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Job run once the Deployment or StatefulSet has rolled out.",
+							Ref:         ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationHook"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationHook"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationImagePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationImagePolicy ...",
+				Properties: map[string]spec.Schema{
+					"pollInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Interval between two checks for a new digest of the image, such as 2m or 1h. Defaults to 5m.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imageStreamTag": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tag of an OpenShift ImageStream in the namespace of the application, as <imagestream>:<tag>, resolved instead of the registry of the image when ImageStreams are available.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationKnative(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationKnative ...",
+				Properties: map[string]spec.Schema{
+					"revisionSuffix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Suffix appended to the application name to form the name of the generated revision. Defaults to a hash of the revision template so the name only changes with the template.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"traffic": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.KnativeTrafficTarget"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.KnativeTrafficTarget"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationScheduling(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationScheduling ...",
+				Properties: map[string]spec.Schema{
+					"nodeSelector": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"tolerations": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Toleration"),
+									},
+								},
+							},
+						},
+					},
+					"priorityClassName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"spreadReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Spreads the replicas of the application across zones or nodes using preferred pod anti-affinity.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.Toleration"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSeccompProfile(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSeccompProfile ...",
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"localhostProfile": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"type"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSecurity(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSecurity ...",
+				Properties: map[string]spec.Schema{
+					"profile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Fills in the settings of the profile that are not set explicitly.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"pod": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.PodSecurityContext"),
+						},
+					},
+					"container": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
+					"seccompProfile": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSeccompProfile"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSeccompProfile", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationService(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationService ...",
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"port": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSet(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSet is the Schema for the appsodyapplicationsets API",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetSpec", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetGenerator(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSetGenerator sets one of list, namespaces or configMap",
+				Properties: map[string]spec.Schema{
+					"list": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Generates an application for each element",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.ApplicationSetListElement"),
+									},
+								},
+							},
+						},
+					},
+					"namespaces": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Generates an application for each namespace matching the selector, with the namespace parameter",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"configMap": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Generates an application for each key of a ConfigMap in the namespace of the set, with the key parameter and the parameters of the JSON object of its value",
+							Ref:         ref("./pkg/apis/appsody/v1alpha1.ApplicationSetConfigMapGenerator"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.ApplicationSetConfigMapGenerator", "./pkg/apis/appsody/v1alpha1.ApplicationSetListElement", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetRollout(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSetRollout ...",
+				Properties: map[string]spec.Schema{
+					"maxUnavailable": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of applications which may be not ready while the template is rolled out. The next applications are updated once the previous ones are ready. Defaults to all the applications.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSetSpec defines the desired state of AppsodyApplicationSet",
+				Properties: map[string]spec.Schema{
+					"template": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetTemplate"),
+						},
+					},
+					"generators": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Each generator produces the parameters of applications, which are rendered from the template",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetGenerator"),
+									},
+								},
+							},
+						},
+					},
+					"rollout": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetRollout"),
+						},
+					},
+				},
+				Required: []string{"template", "generators"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetGenerator", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetRollout", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetTemplate"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSetStatus defines the observed state of AppsodyApplicationSet",
+				Properties: map[string]spec.Schema{
+					"applications": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"readyApplications": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"updatedApplications": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"children": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Applications of the set, in the order of the generators",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.ApplicationSetChildStatus"),
+									},
+								},
+							},
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Error preventing the set from being reconciled",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"applications", "readyApplications", "updatedApplications"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.ApplicationSetChildStatus"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetTemplate(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSetTemplate holds the applications of the set. The {{parameter}} placeholders of its strings are replaced by the parameters of each application.",
+				Properties: map[string]spec.Schema{
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetTemplateMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec"),
+						},
+					},
+				},
+				Required: []string{"metadata", "spec"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSetTemplateMeta", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSetTemplateMeta(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSetTemplateMeta ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Defaults to the namespace of the set",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"labels": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"annotations": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationSpec defines the desired state of AppsodyApplication",
+				Properties: map[string]spec.Schema{
+					"applicationImage": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"autoscaling": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationAutoScaling"),
+						},
+					},
+					"pullPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"pullSecret": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"pullSecrets": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"volumes": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Volume"),
+									},
+								},
+							},
+						},
+					},
+					"volumeMounts": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.VolumeMount"),
+									},
+								},
+							},
+						},
+					},
+					"resourceConstraints": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"readinessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.Probe"),
+						},
+					},
+					"service": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationService"),
+						},
+					},
+					"expose": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"envFrom": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvFromSource"),
+									},
+								},
+							},
+						},
+					},
+					"env": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"rolloutExclusions": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.ConfigReference"),
+									},
+								},
+							},
+						},
+					},
+					"config": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationConfig"),
+						},
+					},
+					"hooks": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationHooks"),
+						},
+					},
+					"serviceAccountName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"patchServiceAccount": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"architecture": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"scheduling": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationScheduling"),
+						},
+					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSecurity"),
+						},
+					},
+					"storage": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationStorage"),
+						},
+					},
+					"createKnativeService": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"knative": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationKnative"),
+						},
+					},
+					"stack": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"workloadKind": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule of the CronJob in Cron format, required when workloadKind is CronJob.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imagePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationImagePolicy"),
+						},
+					},
+					"profile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Profile layered onto the stack defaults. Defaults to the appsody.dev/profile label of the namespace.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"revisionHistoryLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of revisions of the resolved spec kept for rollbacks. Defaults to 10.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"rollbackTo": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Revision of status.revisions whose spec replaces the spec of the application. Cleared once restored.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"dependsOn": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Applications which must be ready before the workload of the application is created or scaled up from 0",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationDependency"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"applicationImage"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationAutoScaling", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationConfig", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationDependency", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationHooks", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationImagePolicy", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationKnative", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationScheduling", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationSecurity", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationService", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationStorage", "./pkg/apis/appsody/v1alpha1.ConfigReference", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationStatus defines the observed state of AppsodyApplication",
+				Properties: map[string]spec.Schema{
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.StatusCondition"),
+									},
+								},
+							},
+						},
+					},
+					"knative": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.KnativeStatus"),
+						},
+					},
+					"volumes": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.VolumeStatus"),
+									},
+								},
+							},
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Generation of the spec the Reconciled condition reports on",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"configHash": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Hash of the contents of the ConfigMaps and Secrets referenced by the application",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"hooks": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.HookStatus"),
+									},
+								},
+							},
+						},
+					},
+					"policyViolations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Fields of the application which differ from the constants of its stack",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.PolicyViolation"),
+									},
+								},
+							},
+						},
+					},
+					"imageDigests": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Digests deployed by the image policy, most recent last",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.ImageDigestStatus"),
+									},
+								},
+							},
+						},
+					},
+					"imageArchitectures": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Architectures supported by the image, read from its registry",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"profile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Profile whose overrides are applied to the stack defaults",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"revisions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Revisions of the resolved spec kept for rollbacks, most recent last",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.RevisionStatus"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.HookStatus", "./pkg/apis/appsody/v1alpha1.ImageDigestStatus", "./pkg/apis/appsody/v1alpha1.KnativeStatus", "./pkg/apis/appsody/v1alpha1.PolicyViolation", "./pkg/apis/appsody/v1alpha1.RevisionStatus", "./pkg/apis/appsody/v1alpha1.StatusCondition", "./pkg/apis/appsody/v1alpha1.VolumeStatus"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStorage(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationStorage ...",
+				Properties: map[string]spec.Schema{
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"mountPath": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"volumeClaimTemplate": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.PersistentVolumeClaim"),
+						},
+					},
+					"volumes": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationVolume"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationVolume", "k8s.io/api/core/v1.PersistentVolumeClaim"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationVolume(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyApplicationVolume ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"mountPath": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"storageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"accessModes": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"volumeMode": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"name", "size", "mountPath"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyPromotion(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyPromotion is the Schema for the appsodypromotions API",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyPromotionSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyPromotionStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.AppsodyPromotionSpec", "./pkg/apis/appsody/v1alpha1.AppsodyPromotionStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyPromotionSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyPromotionSpec defines the desired state of AppsodyPromotion",
+				Properties: map[string]spec.Schema{
+					"source": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.PromotionReference"),
+						},
+					},
+					"target": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/appsody/v1alpha1.PromotionReference"),
+						},
+					},
+					"fields": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Fields of the source spec copied to the target along with the image, such as env or resourceConstraints",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"requireApproval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Waits for the appsody.dev/approved annotation before promoting an image",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"source", "target"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.PromotionReference"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_AppsodyPromotionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AppsodyPromotionStatus defines the observed state of AppsodyPromotion",
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of the source pinned by digest, waiting to be promoted or last promoted",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"history": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Promotions to the target, most recent last",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.PromotionRecord"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.PromotionRecord"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_ConfigReference(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ConfigReference ...",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"kind", "name"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_HookStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "HookStatus ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"jobName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"failedAttempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of failed attempts, retried up to backoffLimit times",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"backoffLimit": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"logsSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Label selector of the pods of the Job, to read the logs of the hook",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_ImageDigestStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ImageDigestStatus ...",
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of the application the digest was resolved from",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"apiVersion": {
+					"pinnedImage": {
 						SchemaProps: spec.SchemaProps{
-							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Description: "Image pinned by digest, deployed in place of the image of the application",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"metadata": {
-						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
-						},
-					},
-					"spec": {
+					"digest": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec"),
+							Type:   []string{"string"},
+							Format: "",
 						},
 					},
-					"status": {
+					"deployedAt": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationStatus"),
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
 				},
+				Required: []string{"image", "pinnedImage", "digest", "deployedAt"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationSpec", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationAutoScaling(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_appsody_v1alpha1_KnativeStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "AppsodyApplicationAutoScaling ...",
+				Description: "KnativeStatus ...",
 				Properties: map[string]spec.Schema{
-					"targetCPUUtilizationPercentage": {
+					"url": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"integer"},
-							Format: "int32",
+							Type:   []string{"string"},
+							Format: "",
 						},
 					},
-					"minReplicas": {
+					"latestCreatedRevisionName": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"integer"},
-							Format: "int32",
+							Type:   []string{"string"},
+							Format: "",
 						},
 					},
-					"maxReplicas": {
+					"latestReadyRevisionName": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"integer"},
-							Format: "int32",
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"traffic": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/appsody/v1alpha1.KnativeTrafficStatus"),
+									},
+								},
+							},
 						},
 					},
 				},
 			},
 		},
-		Dependencies: []string{},
+		Dependencies: []string{
+			"./pkg/apis/appsody/v1alpha1.KnativeTrafficStatus"},
 	}
 }
 
-func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationService(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_appsody_v1alpha1_KnativeTrafficStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "AppsodyApplicationService ...",
+				Description: "KnativeTrafficStatus ...",
 				Properties: map[string]spec.Schema{
-					"type": {
+					"tag": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
-					"port": {
+					"revisionName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"percent": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"integer"},
 							Format: "int32",
 						},
 					},
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
 				},
 			},
 		},
@@ -120,122 +1722,104 @@ func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationService(ref common.Refer
 	}
 }
 
-func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_appsody_v1alpha1_KnativeTrafficTarget(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "AppsodyApplicationSpec defines the desired state of AppsodyApplication",
+				Description: "KnativeTrafficTarget ...",
 				Properties: map[string]spec.Schema{
-					"applicationImage": {
+					"tag": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
-					"replicas": {
-						SchemaProps: spec.SchemaProps{
-							Type:   []string{"integer"},
-							Format: "int32",
-						},
-					},
-					"autoscaling": {
-						SchemaProps: spec.SchemaProps{
-							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationAutoScaling"),
-						},
-					},
-					"pullPolicy": {
+					"revisionName": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
-					"pullSecret": {
+					"latestRevision": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"string"},
+							Type:   []string{"boolean"},
 							Format: "",
 						},
 					},
-					"volumes": {
-						SchemaProps: spec.SchemaProps{
-							Type: []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.Volume"),
-									},
-								},
-							},
-						},
-					},
-					"volumeMounts": {
-						SchemaProps: spec.SchemaProps{
-							Type: []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.VolumeMount"),
-									},
-								},
-							},
-						},
-					},
-					"resourceConstraints": {
-						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/api/core/v1.ResourceRequirements"),
-						},
-					},
-					"readinessProbe": {
+					"percent": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/api/core/v1.Probe"),
+							Type:   []string{"integer"},
+							Format: "int32",
 						},
 					},
-					"livenessProbe": {
+				},
+				Required: []string{"percent"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_PolicyViolation(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PolicyViolation ...",
+				Properties: map[string]spec.Schema{
+					"field": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/api/core/v1.Probe"),
+							Type:   []string{"string"},
+							Format: "",
 						},
 					},
-					"service": {
+					"mode": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationService"),
+							Type:   []string{"string"},
+							Format: "",
 						},
 					},
-					"expose": {
+					"value": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"boolean"},
-							Format: "",
+							Description: "Value of the application, JSON encoded",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"envFrom": {
+					"constant": {
 						SchemaProps: spec.SchemaProps{
-							Type: []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.EnvFromSource"),
-									},
-								},
-							},
+							Description: "Value of the constant, JSON encoded, or the constraint which is not met",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"env": {
+					"action": {
 						SchemaProps: spec.SchemaProps{
-							Type: []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("k8s.io/api/core/v1.EnvVar"),
-									},
-								},
-							},
+							Description: "Action taken for a constraint when the policy is enforced",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"serviceAccountName": {
+				},
+				Required: []string{"field", "mode"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_appsody_v1alpha1_PromotionRecord(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PromotionRecord ...",
+				Properties: map[string]spec.Schema{
+					"image": {
 						SchemaProps: spec.SchemaProps{
 							Type:   []string{"string"},
 							Format: "",
 						},
 					},
-					"architecture": {
+					"fields": {
 						SchemaProps: spec.SchemaProps{
 							Type: []string{"array"},
 							Items: &spec.SchemaOrArray{
@@ -248,87 +1832,91 @@ func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationSpec(ref common.Referenc
 							},
 						},
 					},
-					"storage": {
-						SchemaProps: spec.SchemaProps{
-							Ref: ref("./pkg/apis/appsody/v1alpha1.AppsodyApplicationStorage"),
-						},
-					},
-					"createKnativeService": {
+					"approval": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"boolean"},
-							Format: "",
+							Description: "Value of the approval annotation when the image was promoted",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"stack": {
+					"promotedAt": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"string"},
-							Format: "",
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
 				},
-				Required: []string{"applicationImage", "stack"},
+				Required: []string{"image", "promotedAt"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/appsody/v1alpha1.AppsodyApplicationAutoScaling", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationService", "./pkg/apis/appsody/v1alpha1.AppsodyApplicationStorage", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Probe", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_appsody_v1alpha1_PromotionReference(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "AppsodyApplicationStatus defines the observed state of AppsodyApplication",
+				Description: "PromotionReference ...",
 				Properties: map[string]spec.Schema{
-					"conditions": {
+					"name": {
 						SchemaProps: spec.SchemaProps{
-							Type: []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("./pkg/apis/appsody/v1alpha1.StatusCondition"),
-									},
-								},
-							},
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Defaults to the namespace of the promotion",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
 				},
+				Required: []string{"name"},
 			},
 		},
-		Dependencies: []string{
-			"./pkg/apis/appsody/v1alpha1.StatusCondition"},
+		Dependencies: []string{},
 	}
 }
 
-func schema_pkg_apis_appsody_v1alpha1_AppsodyApplicationStorage(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_appsody_v1alpha1_RevisionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "AppsodyApplicationStorage ...",
+				Description: "RevisionStatus ...",
 				Properties: map[string]spec.Schema{
-					"size": {
+					"revision": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"string"},
-							Format: "",
+							Type:   []string{"integer"},
+							Format: "int64",
 						},
 					},
-					"mountPath": {
+					"name": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"string"},
-							Format: "",
+							Description: "Name of the ControllerRevision holding the spec",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"volumeClaimTemplate": {
+					"image": {
 						SchemaProps: spec.SchemaProps{
-							Ref: ref("k8s.io/api/core/v1.PersistentVolumeClaim"),
+							Description: "Image deployed by the revision, pinned by digest when the image policy resolved it",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"createdAt": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
 				},
-				Required: []string{"mountPath"},
+				Required: []string{"revision", "name", "image", "createdAt"},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/api/core/v1.PersistentVolumeClaim"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -379,3 +1967,41 @@ func schema_pkg_apis_appsody_v1alpha1_StatusCondition(ref common.ReferenceCallba
 			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
+
+func schema_pkg_apis_appsody_v1alpha1_VolumeStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "VolumeStatus ...",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"requestedSize": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"capacity": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
@@ -5,19 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
 	appsodyutils "github.com/appsody-operator/pkg/utils"
-	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
 
+	imagev1 "github.com/openshift/api/image/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -42,8 +48,11 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileAppsodyApplication{ReconcilerBase: appsodyutils.NewReconcilerBase(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig(), mgr.GetRecorder("appsody-operator")),
-		StackDefaults: map[string]appsodyv1alpha1.AppsodyApplicationSpec{}, StackConstants: map[string]*appsodyv1alpha1.AppsodyApplicationSpec{}}
+	r := &ReconcileAppsodyApplication{ReconcilerBase: appsodyutils.NewReconcilerBase(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig(), mgr.GetRecorder("appsody-operator")),
+		StackDefaults: map[string]appsodyv1alpha1.AppsodyApplicationSpec{}, StackConstants: map[string]*appsodyutils.StackConstants{},
+		Profiles: map[string]appsodyv1alpha1.AppsodyApplicationSpec{}}
+	r.SetImageInspector(appsodyutils.NewImageInspector(nil))
+	return r
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -77,9 +86,82 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "appsody-operator-profiles"}}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Roll out applications when the ConfigMaps and Secrets they reference change
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: requestsForConfig(mgr.GetClient(), appsodyv1alpha1.ConfigReferenceKindConfigMap),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: requestsForConfig(mgr.GetClient(), appsodyv1alpha1.ConfigReferenceKindSecret),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Create or scale up the workloads of applications once their dependencies are ready
+	err = c.Watch(&source.Kind{Type: &appsodyv1alpha1.AppsodyApplication{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: requestsForDependency(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// requestsForDependency maps an application to the applications that depend on it, in any namespace
+func requestsForDependency(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		apps := &appsodyv1alpha1.AppsodyApplicationList{}
+		err := c.List(context.TODO(), &client.ListOptions{}, apps)
+		if err != nil {
+			log.Error(err, "Failed to list AppsodyApplications")
+			return nil
+		}
+
+		dependency := types.NamespacedName{Name: a.Meta.GetName(), Namespace: a.Meta.GetNamespace()}
+		requests := []reconcile.Request{}
+		for i := range apps.Items {
+			if appsodyutils.IsDependency(&apps.Items[i], dependency) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: apps.Items[i].Name, Namespace: apps.Items[i].Namespace},
+				})
+			}
+		}
+		return requests
+	}
+}
+
+// requestsForConfig maps a ConfigMap or Secret to the applications in its namespace that reference it
+func requestsForConfig(c client.Client, kind appsodyv1alpha1.ConfigReferenceKind) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		apps := &appsodyv1alpha1.AppsodyApplicationList{}
+		err := c.List(context.TODO(), &client.ListOptions{Namespace: a.Meta.GetNamespace()}, apps)
+		if err != nil {
+			log.Error(err, "Failed to list AppsodyApplications", "Namespace", a.Meta.GetNamespace())
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for i := range apps.Items {
+			if appsodyutils.IsConfigReferenced(&apps.Items[i], kind, a.Meta.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: apps.Items[i].Name, Namespace: apps.Items[i].Namespace},
+				})
+			}
+		}
+		return requests
+	}
+}
+
 // blank assignment to verify that ReconcileAppsodyApplication implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileAppsodyApplication{}
 
@@ -89,7 +171,8 @@ type ReconcileAppsodyApplication struct {
 	// that reads objects from the cache and writes to the apiserver
 	appsodyutils.ReconcilerBase
 	StackDefaults  map[string]appsodyv1alpha1.AppsodyApplicationSpec
-	StackConstants map[string]*appsodyv1alpha1.AppsodyApplicationSpec
+	StackConstants map[string]*appsodyutils.StackConstants
+	Profiles       map[string]appsodyv1alpha1.AppsodyApplicationSpec
 }
 
 // Reconcile reads that state of the cluster for a AppsodyApplication object and makes changes based on the state read
@@ -124,8 +207,11 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 				delete(r.StackConstants, k)
 			}
 			for stack, values := range configMap.Data {
-				var constants appsodyv1alpha1.AppsodyApplicationSpec
+				var constants appsodyutils.StackConstants
 				unerr := json.Unmarshal([]byte(values), &constants)
+				if unerr == nil {
+					unerr = appsodyutils.ValidateStackConstants(&constants)
+				}
 				if unerr != nil {
 					reqLogger.Error(unerr, "Failed to parse config map constants")
 				} else {
@@ -133,6 +219,21 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 				}
 			}
 		}
+		configMap, err = r.GetAppsodyOpConfigMap("appsody-operator-profiles", request.Namespace)
+		if err == nil {
+			for k := range r.Profiles {
+				delete(r.Profiles, k)
+			}
+			for profile, values := range configMap.Data {
+				var overrides appsodyv1alpha1.AppsodyApplicationSpec
+				unerr := json.Unmarshal([]byte(values), &overrides)
+				if unerr != nil {
+					reqLogger.Error(unerr, "Failed to parse config map profiles")
+				} else {
+					r.Profiles[profile] = overrides
+				}
+			}
+		}
 		return reconcile.Result{}, nil
 
 	}
@@ -150,29 +251,134 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
-	stackDefaults, ok := r.StackDefaults[instance.Spec.Stack]
-	if ok {
-		appsodyutils.InitAndValidate(instance, stackDefaults, r.StackConstants[instance.Spec.Stack])
 
-	} else {
-		stackDefaults, ok = r.StackDefaults["generic"]
-		if !ok {
-			err = fmt.Errorf("Failed to find stack `%v` in the ConfigMap holding default values", instance.Spec.Stack)
-			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
-		}
-		appsodyutils.InitAndValidate(instance, stackDefaults, r.StackConstants[instance.Spec.Stack])
+	// The restored spec is saved along with the defaults below
+	err = r.rollback(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to roll back AppsodyApplication")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+
+	metadata, inspectRetry, err := r.inspectImage(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to inspect the application image")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 	}
+	// The port of the image is only known once its registry can be reached, so the default port isn't saved until then
+	portPending := inspectRetry > 0 && (instance.Spec.Service == nil || instance.Spec.Service.Port == 0)
 
-	err = r.GetClient().Update(context.TODO(), instance)
+	stackDefaults, fallback, err := r.getStackDefaults(instance.Spec.Stack)
+	if err != nil {
+		reqLogger.Error(err, "Application stack rejected")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	profile, overrides, err := r.getProfile(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to select the profile of the application")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	if overrides != nil {
+		stackDefaults = appsodyutils.ApplyProfile(stackDefaults, *overrides)
+	}
+	constants := r.StackConstants[instance.Spec.Stack]
+	violations := appsodyutils.InitAndValidate(instance, stackDefaults, constants)
+	constraintViolations, rejected := appsodyutils.ApplyConstraints(instance, constants)
+	violations = append(violations, constraintViolations...)
+
+	if constants != nil && constants.Service != nil && constants.Service.Port != 0 {
+		portPending = false
+	}
+	saved := instance
+	if portPending && instance.Spec.Service != nil {
+		saved = instance.DeepCopy()
+		saved.Spec.Service.Port = 0
+	}
+	err = r.GetClient().Update(context.TODO(), saved)
 	if err != nil {
 		reqLogger.Error(err, "Error updating AppsodyApplication")
 		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 	}
+	instance.ObjectMeta = saved.ObjectMeta
+
+	// Enforced values are no longer visible in the spec once it's updated, so the violations are reported here
+	for _, v := range appsodyutils.UpdatePolicyViolations(instance, violations, constants) {
+		switch {
+		case v.Action == appsodyv1alpha1.ConstraintActionReject:
+			// Reported along with the error below
+		case v.Mode == appsodyv1alpha1.PolicyModeEnforce:
+			r.GetRecorder().Event(instance, "Warning", "PolicyEnforced",
+				fmt.Sprintf("Field %s was changed to comply with stack %s: %s (was %s)", v.Field, instance.Spec.Stack, v.Constant, v.Value))
+		case v.Mode == appsodyv1alpha1.PolicyModeWarn:
+			r.GetRecorder().Event(instance, "Warning", "PolicyViolation",
+				fmt.Sprintf("Field %s differs from the constant of stack %s: %s (expected %s)", v.Field, instance.Spec.Stack, v.Value, v.Constant))
+		}
+	}
+
+	instance.Status.Profile = profile
+
+	// Architectures of the last inspected image are kept while its registry can't be reached
+	if metadata != nil {
+		instance.Status.ImageArchitectures = metadata.Architectures
+	}
+
+	if fallback {
+		if c := appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeStackRecognized, &instance.Status); c == nil || c.Status != corev1.ConditionFalse {
+			r.GetRecorder().Event(instance, "Warning", "UnknownStack",
+				fmt.Sprintf("Stack %s has no defaults, the defaults of the generic stack are used", instance.Spec.Stack))
+		}
+		appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeStackRecognized, corev1.ConditionFalse, "GenericDefaults",
+			fmt.Sprintf("Stack `%v` is not found in the ConfigMap holding default values, the defaults of the `generic` stack are used", instance.Spec.Stack), &instance.Status)
+	} else {
+		appsodyutils.RemoveCondition(appsodyv1alpha1.StatusConditionTypeStackRecognized, &instance.Status)
+	}
+
+	if rejected != nil {
+		reqLogger.Error(rejected, "Application rejected by the constraints of its stack")
+		return r.ManageError(rejected, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+
+	// Pods of an image without a build for the architecture of their node crash, so they are not rolled out
+	if len(instance.Spec.Architecture) > 0 && len(appsodyutils.GetArchitectures(instance)) == 0 {
+		err = fmt.Errorf("Image %s supports none of the architectures %v, only %v", instance.Spec.ApplicationImage,
+			instance.Spec.Architecture, instance.Status.ImageArchitectures)
+		if c := appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeArchitectureSupported, &instance.Status); c == nil || c.Status != corev1.ConditionFalse {
+			r.GetRecorder().Event(instance, "Warning", "UnsupportedArchitecture", err.Error())
+		}
+		appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeArchitectureSupported, corev1.ConditionFalse, "NoSchedulableArchitecture", err.Error(), &instance.Status)
+		reqLogger.Error(err, "No schedulable architecture")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	appsodyutils.RemoveCondition(appsodyv1alpha1.StatusConditionTypeArchitectureSupported, &instance.Status)
 
 	if instance.Generation == 1 {
+		if len(instance.Status.PolicyViolations) > 0 || fallback {
+			err = r.GetClient().Status().Update(context.TODO(), instance)
+			if err != nil {
+				reqLogger.Error(err, "Error updating the policy violations of AppsodyApplication")
+				return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+			}
+		}
 		return reconcile.Result{Requeue: true}, nil
 	}
 
+	instance.Status.ConfigHash, err = r.GetConfigHash(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to compute the hash of the referenced ConfigMaps and Secrets")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+
+	err = r.resolveImageDigest(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to resolve the digest of the application image")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+
+	err = r.recordRevision(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to record the revision of AppsodyApplication")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+
 	defaultMeta := metav1.ObjectMeta{
 		Name:      instance.Name,
 		Namespace: instance.Namespace,
@@ -195,19 +401,103 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 			reqLogger.Error(err, "Failed to delete ServiceAccount")
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
+
+		// The user-provided ServiceAccount is not owned by the application, so pull secrets are only ever added to it
+		if instance.Spec.PatchServiceAccount != nil && *instance.Spec.PatchServiceAccount {
+			userServiceAccount := &corev1.ServiceAccount{}
+			err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: *instance.Spec.ServiceAccountName, Namespace: instance.Namespace}, userServiceAccount)
+			if err == nil && appsodyutils.AddPullSecrets(userServiceAccount, instance) {
+				err = r.GetClient().Update(context.TODO(), userServiceAccount)
+			}
+			if err != nil {
+				reqLogger.Error(err, "Failed to add pull secrets to ServiceAccount", "ServiceAccount", *instance.Spec.ServiceAccountName)
+				return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+			}
+		}
 	}
 
-	if instance.Spec.CreateKnativeService != nil && *instance.Spec.CreateKnativeService {
-		ksvc := &servingv1alpha1.Service{ObjectMeta: defaultMeta}
-		err = r.CreateOrUpdate(ksvc, instance, func() error {
-			appsodyutils.CustomizeKnativeService(ksvc, instance)
+	err = appsodyutils.ValidateConfig(instance)
+	if err != nil {
+		reqLogger.Error(err, "Invalid configuration files")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	configMapName := appsodyutils.GetConfigMapName(instance)
+	if configMapName != "" {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: instance.Namespace}}
+		err = r.CreateOrUpdate(configMap, instance, func() error {
+			appsodyutils.CustomizeConfigMap(configMap, instance)
 			return nil
 		})
+		if err != nil {
+			reqLogger.Error(err, "Failed to reconcile configuration ConfigMap")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+	}
+
+	err = appsodyutils.ValidateWorkloadKind(instance)
+	if err != nil {
+		reqLogger.Error(err, "Invalid workload kind")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	workloadKind := appsodyutils.GetWorkloadKind(instance)
+
+	// Workloads which are already running are not stopped when a dependency becomes unavailable
+	waiting, err := r.getDependenciesWaitedFor(instance, workloadKind)
+	if err != nil {
+		reqLogger.Error(err, "Failed to check the dependencies of AppsodyApplication")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	if len(waiting) > 0 {
+		return r.manageDependenciesPending(instance, waiting)
+	}
+	appsodyutils.RemoveCondition(appsodyv1alpha1.StatusConditionTypeWaitingForDependencies, &instance.Status)
+
+	if workloadKind == appsodyv1alpha1.WorkloadKindKnativeService {
+		err = appsodyutils.ValidateKnativeTraffic(instance)
+		if err != nil {
+			reqLogger.Error(err, "Invalid Knative traffic configuration")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+
+		knativeVersion, err := r.GetKnativeServingVersion()
+		if err != nil {
+			reqLogger.Error(err, "Failed to check which Knative Serving versions are supported")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+		if knativeVersion == "" {
+			err = fmt.Errorf("Knative Serving is not installed on the cluster")
+			reqLogger.Error(err, "Failed to reconcile Knative Service")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+
+		if ignored := appsodyutils.GetIgnoredKnativeFields(instance); len(ignored) > 0 {
+			r.GetRecorder().Event(instance, "Warning", "KnativeFieldsIgnored",
+				fmt.Sprintf("Fields %s are not applied to Knative revisions, as the feature flags they require are not listed in %s",
+					strings.Join(ignored, ", "), appsodyutils.KnativePodSpecFeaturesEnvVar))
+		}
+
+		ksvc := appsodyutils.NewKnativeService(knativeVersion, defaultMeta)
+		err = r.CreateOrUpdate(ksvc, instance, func() error {
+			return appsodyutils.CustomizeUnstructuredKnativeService(ksvc, instance)
+		})
 
 		if err != nil {
 			reqLogger.Error(err, "Failed to reconcile Knative Service")
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
+		if typed, err := appsodyutils.ToKnativeService(ksvc); err == nil {
+			appsodyutils.UpdateKnativeStatus(instance, typed)
+		}
+
+		// Revisions keep mounting the ConfigMap they were created with for as long as they receive traffic
+		served, known, err := r.getServedConfigMaps(instance, knativeVersion)
+		if err == nil && known {
+			err = r.deleteStaleConfigMaps(instance, append(served, configMapName)...)
+		}
+		if err != nil {
+			reqLogger.Error(err, "Failed to delete outdated configuration ConfigMaps")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
 
 		// Clean up non-Knative resources
 		resources := []runtime.Object{
@@ -215,6 +505,8 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: instance.Name + "-headless", Namespace: instance.Namespace}},
 			&appsv1.Deployment{ObjectMeta: defaultMeta},
 			&appsv1.StatefulSet{ObjectMeta: defaultMeta},
+			&appsv1.DaemonSet{ObjectMeta: defaultMeta},
+			&batchv1beta1.CronJob{ObjectMeta: defaultMeta},
 			&routev1.Route{ObjectMeta: defaultMeta},
 			&autoscalingv1.HorizontalPodAutoscaler{ObjectMeta: defaultMeta},
 		}
@@ -224,43 +516,88 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
 
-		return r.ManageSuccess(appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		// Hooks gate the rollout of Deployments and StatefulSets only
+		instance.Status.Hooks = nil
+		result, err := r.ManageSuccess(appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		if inspectRetry > 0 {
+			result = requeueWithin(result, inspectRetry)
+		}
+		return withImagePolling(instance, result, err)
 	}
 
+	instance.Status.Knative = nil
+
 	// Check if Knative is supported and delete Knative service if supported
-	if ok, err = r.IsGroupVersionSupported(servingv1alpha1.SchemeGroupVersion.String()); err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Failed to check if %s is supported", servingv1alpha1.SchemeGroupVersion.String()))
+	if knativeVersion, err := r.GetKnativeServingVersion(); err != nil {
+		reqLogger.Error(err, "Failed to check which Knative Serving versions are supported")
 		r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
-	} else if ok {
-		ksvc := &servingv1alpha1.Service{ObjectMeta: defaultMeta}
+	} else if knativeVersion != "" {
+		ksvc := appsodyutils.NewKnativeService(knativeVersion, defaultMeta)
 		err = r.DeleteResource(ksvc)
 		if err != nil {
 			reqLogger.Error(err, "Failed to delete Knative Service")
 			r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
 	} else {
-		reqLogger.V(1).Info(fmt.Sprintf("%s is not supported. Skip deleting the resource", servingv1alpha1.SchemeGroupVersion.String()))
+		reqLogger.V(1).Info("Knative Serving is not supported. Skip deleting the resource")
 	}
 
 	svc := &corev1.Service{ObjectMeta: defaultMeta}
-	err = r.CreateOrUpdate(svc, instance, func() error {
-		appsodyutils.CustomizeService(svc, instance)
-		return nil
-	})
+	if workloadKind == appsodyv1alpha1.WorkloadKindCronJob {
+		// Jobs don't receive traffic
+		err = r.DeleteResource(svc)
+		if err != nil {
+			reqLogger.Error(err, "Failed to delete Service")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+	} else {
+		err = r.CreateOrUpdate(svc, instance, func() error {
+			appsodyutils.CustomizeService(svc, instance)
+			return nil
+		})
+		if err != nil {
+			reqLogger.Error(err, "Failed to reconcile Service")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+	}
+
+	// The workload keeps running the previous version until the preDeploy hook succeeds
+	preDeploy, err := r.reconcileHook(instance, appsodyv1alpha1.HookNamePreDeploy, true)
 	if err != nil {
-		reqLogger.Error(err, "Failed to reconcile Service")
+		reqLogger.Error(err, "Failed to reconcile preDeploy hook")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	if preDeploy != nil && preDeploy.Phase == appsodyv1alpha1.HookPhaseFailed {
+		err = fmt.Errorf("preDeploy hook Job %s failed: %s", preDeploy.JobName, preDeploy.Message)
 		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 	}
+	if preDeploy != nil && preDeploy.Phase != appsodyv1alpha1.HookPhaseSucceeded {
+		return r.manageHooksPending(instance)
+	}
 
-	if instance.Spec.Storage != nil {
-		// Delete Deployment if exists
-		deploy := &appsv1.Deployment{ObjectMeta: defaultMeta}
-		err = r.DeleteResource(deploy)
+	// Switching kinds removes the workload of the previous kind
+	err = r.deleteOtherWorkloads(instance, workloadKind)
+	if err != nil {
+		reqLogger.Error(err, "Failed to clean up workloads of other kinds")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	if workloadKind != appsodyv1alpha1.WorkloadKindStatefulSet {
+		instance.Status.Volumes = nil
+		appsodyutils.RemoveCondition(appsodyv1alpha1.StatusConditionTypeVolumeExpansion, &instance.Status)
+	}
 
-		if err != nil {
-			reqLogger.Error(err, "Failed to delete Deployment")
-			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	expanding := false
+	rolledOut := false
+	switch workloadKind {
+	case appsodyv1alpha1.WorkloadKindStatefulSet:
+		if instance.Spec.Storage != nil {
+			err = appsodyutils.ValidateStorage(instance)
+			if err != nil {
+				reqLogger.Error(err, "Invalid storage configuration")
+				return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+			}
 		}
+
 		svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: instance.Name + "-headless", Namespace: instance.Namespace}}
 		err = r.CreateOrUpdate(svc, instance, func() error {
 			appsodyutils.CustomizeService(svc, instance)
@@ -273,8 +610,24 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
 
-		statefulSet := &appsv1.StatefulSet{ObjectMeta: defaultMeta}
+		// Claim templates are immutable, so the StatefulSet is recreated when the list of volumes changes.
+		// Orphaning keeps the pods running until the new StatefulSet adopts them and PVCs are never deleted.
+		statefulSet := &appsv1.StatefulSet{}
+		err = r.GetClient().Get(context.TODO(), request.NamespacedName, statefulSet)
+		if err == nil && !appsodyutils.HasVolumeClaimTemplates(statefulSet, instance) {
+			err = r.GetClient().Delete(context.TODO(), statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+			if err != nil {
+				reqLogger.Error(err, "Failed to delete StatefulSet with outdated volume claim templates")
+				return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+			}
+		} else if err != nil && !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to get StatefulSet")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+
+		statefulSet = &appsv1.StatefulSet{ObjectMeta: defaultMeta}
 		err = r.CreateOrUpdate(statefulSet, instance, func() error {
+			appsodyutils.CustomizeObjectMeta(&statefulSet.ObjectMeta, instance)
 			statefulSet.Spec.Replicas = instance.Spec.Replicas
 			statefulSet.Spec.ServiceName = instance.Name + "-headless"
 			statefulSet.Spec.Selector = &metav1.LabelSelector{
@@ -290,25 +643,47 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 			reqLogger.Error(err, "Failed to reconcile StatefulSet")
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
-	} else {
-		// Delete StatefulSet if exists
-		statefulSet := &appsv1.StatefulSet{ObjectMeta: defaultMeta}
-		err = r.DeleteResource(statefulSet)
+
+		rolledOut = appsodyutils.IsStatefulSetRolledOut(statefulSet)
+
+		expanding, err = r.expandVolumeClaims(instance, statefulSet)
 		if err != nil {
-			reqLogger.Error(err, "Failed to delete Statefulset")
+			reqLogger.Error(err, "Failed to expand PersistentVolumeClaims")
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
-
-		// Delete StatefulSet if exists
-		headlesssvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: instance.Name + "-headless", Namespace: instance.Namespace}}
-		err = r.DeleteResource(headlesssvc)
-
+	case appsodyv1alpha1.WorkloadKindDaemonSet:
+		daemonSet := &appsv1.DaemonSet{ObjectMeta: defaultMeta}
+		err = r.CreateOrUpdate(daemonSet, instance, func() error {
+			appsodyutils.CustomizeObjectMeta(&daemonSet.ObjectMeta, instance)
+			daemonSet.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/name": instance.Name,
+				},
+			}
+			appsodyutils.CustomizePodSpec(&daemonSet.Spec.Template, instance)
+			return nil
+		})
 		if err != nil {
-			reqLogger.Error(err, "Failed to delete headless Service")
+			reqLogger.Error(err, "Failed to reconcile DaemonSet")
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
+		rolledOut = appsodyutils.IsDaemonSetRolledOut(daemonSet)
+	case appsodyv1alpha1.WorkloadKindCronJob:
+		cronJob := &batchv1beta1.CronJob{ObjectMeta: defaultMeta}
+		err = r.CreateOrUpdate(cronJob, instance, func() error {
+			appsodyutils.CustomizeCronJob(cronJob, instance)
+			return nil
+		})
+		if err != nil {
+			reqLogger.Error(err, "Failed to reconcile CronJob")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+		// Jobs started by the new schedule already run the new version
+		rolledOut = true
+	default:
 		deploy := &appsv1.Deployment{ObjectMeta: defaultMeta}
 		err = r.CreateOrUpdate(deploy, instance, func() error {
+			appsodyutils.CustomizeObjectMeta(&deploy.ObjectMeta, instance)
 			deploy.Spec.Replicas = instance.Spec.Replicas
 			deploy.Spec.Selector = &metav1.LabelSelector{
 				MatchLabels: map[string]string{
@@ -322,7 +697,29 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 			reqLogger.Error(err, "Failed to reconcile Deployment")
 			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 		}
+		rolledOut = appsodyutils.IsDeploymentRolledOut(deploy)
+	}
+
+	// Knative keeps the ConfigMaps of older revisions that may still receive traffic, so they are only
+	// cleaned up for workloads which roll out completely, once no pod of an older version mounts them
+	if rolledOut {
+		err = r.deleteStaleConfigMaps(instance, configMapName)
+		if err != nil {
+			reqLogger.Error(err, "Failed to delete outdated configuration ConfigMaps")
+			return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+		}
+	}
+
+	postDeploy, err := r.reconcileHook(instance, appsodyv1alpha1.HookNamePostDeploy, rolledOut)
+	if err != nil {
+		reqLogger.Error(err, "Failed to reconcile postDeploy hook")
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	}
+	if postDeploy != nil && postDeploy.Phase == appsodyv1alpha1.HookPhaseFailed {
+		err = fmt.Errorf("postDeploy hook Job %s failed: %s", postDeploy.JobName, postDeploy.Message)
+		return r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 	}
+	hooksPending := postDeploy != nil && postDeploy.Phase != appsodyv1alpha1.HookPhaseSucceeded
 
 	if instance.Spec.Autoscaling != nil {
 		hpa := &autoscalingv1.HorizontalPodAutoscaler{ObjectMeta: defaultMeta}
@@ -348,7 +745,7 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 		reqLogger.Error(err, fmt.Sprintf("Failed to check if %s is supported", routev1.SchemeGroupVersion.String()))
 		r.ManageError(err, appsodyv1alpha1.StatusConditionTypeReconciled, instance)
 	} else if ok {
-		if instance.Spec.Expose != nil && *instance.Spec.Expose {
+		if instance.Spec.Expose != nil && *instance.Spec.Expose && workloadKind != appsodyv1alpha1.WorkloadKindCronJob {
 			route := &routev1.Route{ObjectMeta: defaultMeta}
 			err = r.CreateOrUpdate(route, instance, func() error {
 				appsodyutils.CustomizeRoute(route, instance)
@@ -370,5 +767,661 @@ func (r *ReconcileAppsodyApplication) Reconcile(request reconcile.Request) (reco
 		reqLogger.V(1).Info(fmt.Sprintf("%s is not supported", routev1.SchemeGroupVersion.String()))
 	}
 
-	return r.ManageSuccess(appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	if hooksPending {
+		return r.manageHooksPending(instance)
+	}
+
+	result, err := r.ManageSuccess(appsodyv1alpha1.StatusConditionTypeReconciled, instance)
+	if err == nil && !result.Requeue && expanding {
+		// PVCs don't notify the CR, so poll until the expansion completes
+		result.RequeueAfter = 30 * time.Second
+	}
+	if inspectRetry > 0 {
+		result = requeueWithin(result, inspectRetry)
+	}
+	return withImagePolling(instance, result, err)
+}
+
+// requeueWithin requeues the request after the interval, unless it is requeued earlier
+func requeueWithin(result reconcile.Result, interval time.Duration) reconcile.Result {
+	if !result.Requeue && (result.RequeueAfter == 0 || interval < result.RequeueAfter) {
+		result.RequeueAfter = interval
+	}
+	return result
+}
+
+// inspectImage fills in the stack and the port of the application from the config of its image, and checks that
+// the declared stack matches the one the image was built from. Registry errors are only logged, leaving
+// applications without a stack to the unknown stack policy, and return how long until the image is inspected again.
+func (r *ReconcileAppsodyApplication) inspectImage(cr *appsodyv1alpha1.AppsodyApplication) (*appsodyutils.ImageMetadata, time.Duration, error) {
+	inspector := r.GetImageInspector()
+	if inspector == nil || cr.Spec.ApplicationImage == "" {
+		return nil, 0, nil
+	}
+
+	secrets, err := r.GetPullSecretObjects(cr)
+	if err != nil {
+		return nil, 0, err
+	}
+	metadata, err := inspector.Inspect(cr.Spec.ApplicationImage, secrets)
+	if err != nil {
+		log.Error(err, "Failed to read the config of the application image", "Image", cr.Spec.ApplicationImage)
+		return nil, inspector.GetRetryInterval(cr.Spec.ApplicationImage, secrets), nil
+	}
+	if metadata.StackID != "" && cr.Spec.Stack == "" {
+		cr.Spec.Stack = metadata.StackID
+	} else if metadata.StackID != "" && cr.Spec.Stack != metadata.StackID {
+		return nil, 0, fmt.Errorf("Stack `%v` doesn't match the stack `%v` image %s was built from", cr.Spec.Stack, metadata.StackID, cr.Spec.ApplicationImage)
+	}
+	if len(metadata.Ports) > 0 && (cr.Spec.Service == nil || cr.Spec.Service.Port == 0) {
+		service := &appsodyv1alpha1.AppsodyApplicationService{}
+		if cr.Spec.Service != nil {
+			service = cr.Spec.Service.DeepCopy()
+		}
+		service.Port = metadata.Ports[0]
+		cr.Spec.Service = service
+	}
+	return metadata, 0, nil
+}
+
+// getProfile returns the name and the overrides of the profile of the application, selected by `profile` or by the
+// label of its namespace. Namespaces are only read when profiles are defined.
+func (r *ReconcileAppsodyApplication) getProfile(cr *appsodyv1alpha1.AppsodyApplication) (string, *appsodyv1alpha1.AppsodyApplicationSpec, error) {
+	name := cr.Spec.Profile
+	if name == "" && len(r.Profiles) > 0 {
+		reader, err := r.GetAPIReader()
+		if err != nil {
+			return "", nil, err
+		}
+		ns := &corev1.Namespace{}
+		err = reader.Get(context.TODO(), types.NamespacedName{Name: cr.Namespace}, ns)
+		if err != nil {
+			return "", nil, err
+		}
+		name = ns.Labels[appsodyutils.ProfileLabel]
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+	overrides, ok := r.Profiles[name]
+	if !ok {
+		return "", nil, fmt.Errorf("Profile `%v` is not found in the ConfigMap holding profiles", name)
+	}
+	return name, &overrides, nil
+}
+
+// resolveImageDigest pins the image of an application with an image policy to the digest its tag currently refers
+// to, read from the ImageStream tag of the policy when ImageStreams are available and from the registry otherwise.
+// The last resolved digest stays deployed while the registry can't be reached.
+func (r *ReconcileAppsodyApplication) resolveImageDigest(cr *appsodyv1alpha1.AppsodyApplication) error {
+	if cr.Spec.ImagePolicy == nil {
+		cr.Status.ImageDigests = nil
+		return nil
+	}
+	err := appsodyutils.ValidateImagePolicy(cr)
+	if err != nil {
+		return err
+	}
+
+	pinnedImage, digest := "", ""
+	if tag := cr.Spec.ImagePolicy.ImageStreamTag; tag != "" {
+		ok, err := r.IsGroupVersionSupported(imagev1.SchemeGroupVersion.String())
+		if err != nil {
+			return err
+		}
+		if ok {
+			parts := strings.Split(tag, ":")
+			is := &imagev1.ImageStream{}
+			err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: parts[0], Namespace: cr.Namespace}, is)
+			if err != nil {
+				return err
+			}
+			if pinnedImage, digest, ok = appsodyutils.GetImageStreamTagImage(is, parts[1]); !ok {
+				return fmt.Errorf("ImageStream tag %s has no image", tag)
+			}
+		}
+	}
+
+	if digest == "" {
+		inspector := r.GetImageInspector()
+		if inspector == nil {
+			return nil
+		}
+		secrets, err := r.GetPullSecretObjects(cr)
+		if err != nil {
+			return err
+		}
+		digest, err = inspector.ResolveDigest(cr.Spec.ApplicationImage, secrets)
+		if err != nil {
+			if appsodyutils.GetImage(cr) != cr.Spec.ApplicationImage {
+				log.Error(err, "Failed to resolve the digest of the application image, keeping the deployed digest", "Image", cr.Spec.ApplicationImage)
+				return nil
+			}
+			return err
+		}
+		pinnedImage, err = appsodyutils.PinImageDigest(cr.Spec.ApplicationImage, digest)
+		if err != nil {
+			return err
+		}
+	}
+
+	if appsodyutils.RecordImageDigest(cr, pinnedImage, digest) {
+		r.GetRecorder().Event(cr, "Normal", "ImageDigestChanged", fmt.Sprintf("Image %s is deployed as %s", cr.Spec.ApplicationImage, pinnedImage))
+	}
+	return nil
+}
+
+// getRevisions returns the ControllerRevisions of the application, from the oldest to the most recent
+func (r *ReconcileAppsodyApplication) getRevisions(cr *appsodyv1alpha1.AppsodyApplication) ([]appsv1.ControllerRevision, error) {
+	list := &appsv1.ControllerRevisionList{}
+	opts := client.InNamespace(cr.Namespace).MatchingLabels(map[string]string{appsodyutils.RevisionOfLabel: cr.Name})
+	err := r.GetClient().List(context.TODO(), opts, list)
+	if err != nil {
+		return nil, err
+	}
+	revisions := []appsv1.ControllerRevision{}
+	for _, rev := range list.Items {
+		if rev.Labels[appsodyutils.RevisionOfLabel] == cr.Name && metav1.IsControlledBy(&rev, cr) {
+			revisions = append(revisions, rev)
+		}
+	}
+	appsodyutils.SortRevisions(revisions)
+	return revisions, nil
+}
+
+// rollback replaces the spec of the application with the spec of the revision set in rollbackTo
+func (r *ReconcileAppsodyApplication) rollback(cr *appsodyv1alpha1.AppsodyApplication) error {
+	if cr.Spec.RollbackTo == nil {
+		return nil
+	}
+	revision := *cr.Spec.RollbackTo
+	cr.Spec.RollbackTo = nil
+
+	revisions, err := r.getRevisions(cr)
+	if err != nil {
+		return err
+	}
+	for _, rev := range revisions {
+		if rev.Revision != revision {
+			continue
+		}
+		spec := appsodyv1alpha1.AppsodyApplicationSpec{}
+		err = json.Unmarshal(rev.Data.Raw, &spec)
+		if err != nil {
+			return fmt.Errorf("Failed to read the spec of revision %d: %v", revision, err)
+		}
+		cr.Spec = spec
+		r.GetRecorder().Event(cr, "Normal", "RolledBack", fmt.Sprintf("Application is rolled back to revision %d", revision))
+		return nil
+	}
+	r.GetRecorder().Event(cr, "Warning", "RollbackRevisionNotFound", fmt.Sprintf("Revision %d is not found, the application is not rolled back", revision))
+	return nil
+}
+
+// recordRevision keeps the resolved spec of the application as its most recent ControllerRevision, and deletes the
+// oldest revisions over the history limit
+func (r *ReconcileAppsodyApplication) recordRevision(cr *appsodyv1alpha1.AppsodyApplication) error {
+	data, err := json.Marshal(appsodyutils.GetRevisionSpec(cr))
+	if err != nil {
+		return err
+	}
+	revisions, err := r.getRevisions(cr)
+	if err != nil {
+		return err
+	}
+
+	name := appsodyutils.GetRevisionName(cr, data)
+	latest := int64(0)
+	if len(revisions) > 0 {
+		latest = revisions[len(revisions)-1].Revision
+	}
+	// A spec which was recorded before, as after a rollback, moves to the most recent revision
+	if latest == 0 || revisions[len(revisions)-1].Name != name {
+		rev := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cr.Namespace}}
+		err = r.CreateOrUpdate(rev, cr, func() error {
+			appsodyutils.CustomizeControllerRevision(rev, cr, data, latest+1)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		kept := []appsv1.ControllerRevision{}
+		for _, existing := range revisions {
+			if existing.Name != name {
+				kept = append(kept, existing)
+			}
+		}
+		revisions = append(kept, *rev)
+	}
+
+	limit := appsodyutils.GetRevisionHistoryLimit(cr)
+	for len(revisions) > limit {
+		err = r.DeleteResource(&revisions[0])
+		if err != nil {
+			return err
+		}
+		revisions = revisions[1:]
+	}
+
+	cr.Status.Revisions = nil
+	for i := range revisions {
+		status, err := appsodyutils.GetRevisionStatus(&revisions[i])
+		if err != nil {
+			return err
+		}
+		cr.Status.Revisions = append(cr.Status.Revisions, status)
+	}
+	return nil
+}
+
+// withImagePolling requeues applications with an image policy to check for new digests of their image
+func withImagePolling(cr *appsodyv1alpha1.AppsodyApplication, result reconcile.Result, err error) (reconcile.Result, error) {
+	if err != nil || result.Requeue || cr.Spec.ImagePolicy == nil {
+		return result, err
+	}
+	interval, err := appsodyutils.GetImagePollInterval(cr)
+	if err == nil && (result.RequeueAfter == 0 || interval < result.RequeueAfter) {
+		result.RequeueAfter = interval
+	}
+	return result, nil
+}
+
+// getStackDefaults returns the defaults of the stack, following the policy of the operator for stacks without
+// defaults, and whether they are the defaults of the generic stack
+func (r *ReconcileAppsodyApplication) getStackDefaults(stack string) (appsodyv1alpha1.AppsodyApplicationSpec, bool, error) {
+	policy, err := appsodyutils.GetUnknownStackPolicy()
+	if err != nil {
+		return appsodyv1alpha1.AppsodyApplicationSpec{}, false, err
+	}
+	if policy == appsodyutils.UnknownStackPolicyAllowlist && !appsodyutils.IsStackAllowed(stack) {
+		return appsodyv1alpha1.AppsodyApplicationSpec{}, false, fmt.Errorf("Stack `%v` is not allowed by the operator", stack)
+	}
+	if defaults, ok := r.StackDefaults[stack]; ok {
+		return defaults, false, nil
+	}
+	if policy == appsodyutils.UnknownStackPolicyStrict {
+		return appsodyv1alpha1.AppsodyApplicationSpec{}, false, fmt.Errorf("Failed to find stack `%v` in the ConfigMap holding default values", stack)
+	}
+	defaults, ok := r.StackDefaults["generic"]
+	if !ok {
+		return appsodyv1alpha1.AppsodyApplicationSpec{}, false, fmt.Errorf("Failed to find stack `%v` in the ConfigMap holding default values", stack)
+	}
+	return defaults, true, nil
+}
+
+// deleteOtherWorkloads deletes the workloads the application runs on when its kind is not the given one
+func (r *ReconcileAppsodyApplication) deleteOtherWorkloads(cr *appsodyv1alpha1.AppsodyApplication, kind appsodyv1alpha1.WorkloadKind) error {
+	meta := metav1.ObjectMeta{Name: cr.Name, Namespace: cr.Namespace}
+	workloads := map[appsodyv1alpha1.WorkloadKind][]runtime.Object{
+		appsodyv1alpha1.WorkloadKindDeployment: {&appsv1.Deployment{ObjectMeta: meta}},
+		appsodyv1alpha1.WorkloadKindStatefulSet: {
+			&appsv1.StatefulSet{ObjectMeta: meta},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: cr.Name + "-headless", Namespace: cr.Namespace}},
+		},
+		appsodyv1alpha1.WorkloadKindDaemonSet: {&appsv1.DaemonSet{ObjectMeta: meta}},
+		appsodyv1alpha1.WorkloadKindCronJob:   {&batchv1beta1.CronJob{ObjectMeta: meta}},
+	}
+	for k, resources := range workloads {
+		if k == kind {
+			continue
+		}
+		if err := r.DeleteResources(resources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manageHooksPending records the progress of the hooks and polls until they complete, as neither Jobs
+// nor the rollout of the workload notify the CR
+func (r *ReconcileAppsodyApplication) manageHooksPending(cr *appsodyv1alpha1.AppsodyApplication) (reconcile.Result, error) {
+	result, err := r.ManageSuccess(appsodyv1alpha1.StatusConditionTypeReconciled, cr)
+	if err == nil && !result.Requeue {
+		result.RequeueAfter = 10 * time.Second
+	}
+	return result, err
+}
+
+// manageDependenciesPending reports the dependencies the workload of the application waits for, and checks them
+// again later as their workloads don't notify the application once they are available
+func (r *ReconcileAppsodyApplication) manageDependenciesPending(cr *appsodyv1alpha1.AppsodyApplication, waiting []string) (reconcile.Result, error) {
+	message := fmt.Sprintf("Waiting for applications %s to be ready", strings.Join(waiting, ", "))
+	if c := appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeWaitingForDependencies, &cr.Status); c == nil || c.Status != corev1.ConditionTrue {
+		r.GetRecorder().Event(cr, "Normal", "WaitingForDependencies", message)
+	}
+	appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeWaitingForDependencies, corev1.ConditionTrue, "DependenciesNotReady", message, &cr.Status)
+	result, err := r.ManageSuccess(appsodyv1alpha1.StatusConditionTypeReconciled, cr)
+	if err == nil && !result.Requeue {
+		result.RequeueAfter = 10 * time.Second
+	}
+	return result, err
+}
+
+// getDependenciesWaitedFor returns the dependencies which are not ready, if the workload of the application doesn't
+// exist yet or is scaled to 0. Returns an error when the application depends on itself.
+func (r *ReconcileAppsodyApplication) getDependenciesWaitedFor(cr *appsodyv1alpha1.AppsodyApplication, kind appsodyv1alpha1.WorkloadKind) ([]string, error) {
+	if len(cr.Spec.DependsOn) == 0 {
+		return nil, nil
+	}
+	err := appsodyutils.ValidateDependencies(cr)
+	if err != nil {
+		return nil, err
+	}
+	cycle, err := r.findDependencyCycle(cr)
+	if err != nil {
+		return nil, err
+	}
+	if cycle != nil {
+		err = fmt.Errorf("Dependency cycle: %s", strings.Join(cycle, " -> "))
+		appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeWaitingForDependencies, corev1.ConditionTrue, "DependencyCycle", err.Error(), &cr.Status)
+		return nil, err
+	}
+
+	waiting := []string{}
+	for _, name := range appsodyutils.GetDependencies(cr) {
+		reader, err := r.getDependencyReader(cr, name.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		dep := &appsodyv1alpha1.AppsodyApplication{}
+		err = reader.Get(context.TODO(), name, dep)
+		ready := false
+		if err == nil {
+			ready, err = isDependencyReady(reader, dep)
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if !ready {
+			waiting = append(waiting, name.String())
+		}
+	}
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	stopped, err := r.isWorkloadStopped(cr, kind)
+	if err != nil || !stopped {
+		return nil, err
+	}
+	return waiting, nil
+}
+
+// findDependencyCycle returns the applications of a cycle of dependencies going through the application, if any
+func (r *ReconcileAppsodyApplication) findDependencyCycle(cr *appsodyv1alpha1.AppsodyApplication) ([]string, error) {
+	root := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
+	visited := map[types.NamespacedName]bool{}
+	var visit func(app *appsodyv1alpha1.AppsodyApplication, path []string) ([]string, error)
+	visit = func(app *appsodyv1alpha1.AppsodyApplication, path []string) ([]string, error) {
+		for _, name := range appsodyutils.GetDependencies(app) {
+			if name == root {
+				return append(path, name.String()), nil
+			}
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			reader, err := r.getDependencyReader(cr, name.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			dep := &appsodyv1alpha1.AppsodyApplication{}
+			err = reader.Get(context.TODO(), name, dep)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			cycle, err := visit(dep, append(append([]string{}, path...), name.String()))
+			if cycle != nil || err != nil {
+				return cycle, err
+			}
+		}
+		return nil, nil
+	}
+	return visit(cr, []string{root.String()})
+}
+
+// getDependencyReader returns the client reading the dependencies of the application in the namespace. Dependencies
+// in other namespaces are read from the API server, as the operator may not watch their namespace.
+func (r *ReconcileAppsodyApplication) getDependencyReader(cr *appsodyv1alpha1.AppsodyApplication, namespace string) (client.Reader, error) {
+	if namespace == cr.Namespace {
+		return r.GetClient(), nil
+	}
+	return r.GetAPIReader()
+}
+
+// isDependencyReady returns whether the application is reconciled and its workload has available pods
+func isDependencyReady(reader client.Reader, cr *appsodyv1alpha1.AppsodyApplication) (bool, error) {
+	if !appsodyutils.IsApplicationReady(cr) {
+		return false, nil
+	}
+	var workload runtime.Object
+	var available func() bool
+	switch appsodyutils.GetWorkloadKind(cr) {
+	case appsodyv1alpha1.WorkloadKindKnativeService:
+		return cr.Status.Knative != nil && cr.Status.Knative.LatestReadyRevisionName != "", nil
+	case appsodyv1alpha1.WorkloadKindCronJob:
+		return true, nil
+	case appsodyv1alpha1.WorkloadKindStatefulSet:
+		statefulSet := &appsv1.StatefulSet{}
+		workload, available = statefulSet, func() bool { return statefulSet.Status.ReadyReplicas > 0 }
+	case appsodyv1alpha1.WorkloadKindDaemonSet:
+		daemonSet := &appsv1.DaemonSet{}
+		workload, available = daemonSet, func() bool { return daemonSet.Status.NumberAvailable > 0 }
+	default:
+		deploy := &appsv1.Deployment{}
+		workload, available = deploy, func() bool { return deploy.Status.AvailableReplicas > 0 }
+	}
+
+	err := reader.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, workload)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return available(), nil
+}
+
+// isWorkloadStopped returns whether the workload of the application doesn't exist or is scaled to 0
+func (r *ReconcileAppsodyApplication) isWorkloadStopped(cr *appsodyv1alpha1.AppsodyApplication, kind appsodyv1alpha1.WorkloadKind) (bool, error) {
+	meta := metav1.ObjectMeta{Name: cr.Name, Namespace: cr.Namespace}
+	var replicas func() *int32
+	var workload runtime.Object
+	switch kind {
+	case appsodyv1alpha1.WorkloadKindKnativeService:
+		knativeVersion, err := r.GetKnativeServingVersion()
+		if err != nil || knativeVersion == "" {
+			return true, err
+		}
+		workload = appsodyutils.NewKnativeService(knativeVersion, meta)
+	case appsodyv1alpha1.WorkloadKindCronJob:
+		workload = &batchv1beta1.CronJob{ObjectMeta: meta}
+	case appsodyv1alpha1.WorkloadKindDaemonSet:
+		workload = &appsv1.DaemonSet{ObjectMeta: meta}
+	case appsodyv1alpha1.WorkloadKindStatefulSet:
+		statefulSet := &appsv1.StatefulSet{ObjectMeta: meta}
+		workload, replicas = statefulSet, func() *int32 { return statefulSet.Spec.Replicas }
+	default:
+		deploy := &appsv1.Deployment{ObjectMeta: meta}
+		workload, replicas = deploy, func() *int32 { return deploy.Spec.Replicas }
+	}
+
+	err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, workload)
+	if errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return replicas != nil && replicas() != nil && *replicas() == 0, nil
+}
+
+// reconcileHook runs the Job of the hook for the current pod template once ready is true, and records its
+// progress in the status. Jobs of earlier pod templates are deleted. Returns nil if the hook is not set.
+func (r *ReconcileAppsodyApplication) reconcileHook(cr *appsodyv1alpha1.AppsodyApplication, name appsodyv1alpha1.HookName, ready bool) (*appsodyv1alpha1.HookStatus, error) {
+	jobName := ""
+	var status *appsodyv1alpha1.HookStatus
+	if appsodyutils.GetHook(cr, name) != nil {
+		jobName = appsodyutils.GetHookJobName(cr, name)
+		status = &appsodyv1alpha1.HookStatus{Name: name, JobName: jobName, Phase: appsodyv1alpha1.HookPhaseWaiting}
+	}
+
+	if status != nil && ready {
+		job := &batchv1.Job{}
+		err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: jobName, Namespace: cr.Namespace}, job)
+		if errors.IsNotFound(err) {
+			job = &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: cr.Namespace}}
+			appsodyutils.CustomizeHookJob(job, cr, name)
+			if err = controllerutil.SetControllerReference(cr, job, r.GetScheme()); err == nil {
+				err = r.GetClient().Create(context.TODO(), job)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		hookStatus := appsodyutils.GetHookStatus(job, name)
+		status = &hookStatus
+	}
+
+	jobs := &batchv1.JobList{}
+	opts := client.InNamespace(cr.Namespace).MatchingLabels(map[string]string{
+		appsodyutils.HookOfLabel: cr.Name,
+		appsodyutils.HookLabel:   string(name),
+	})
+	err := r.GetClient().List(context.TODO(), opts, jobs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Name != jobName && job.Labels[appsodyutils.HookLabel] == string(name) && metav1.IsControlledBy(job, cr) {
+			err = r.GetClient().Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+
+	if status == nil {
+		appsodyutils.RemoveHookStatus(name, &cr.Status)
+	} else {
+		appsodyutils.SetHookStatus(*status, &cr.Status)
+	}
+	return status, nil
+}
+
+// expandVolumeClaims grows the PVCs created from the claim templates of the StatefulSet to the size requested in
+// the CR. Claim templates of a StatefulSet are immutable, so the existing PVCs are patched directly. Returns true
+// while any of the PVCs is still being resized.
+func (r *ReconcileAppsodyApplication) expandVolumeClaims(cr *appsodyv1alpha1.AppsodyApplication, statefulSet *appsv1.StatefulSet) (bool, error) {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err := r.GetClient().List(context.TODO(), &client.ListOptions{Namespace: cr.Namespace}, pvcList)
+	if err != nil {
+		return false, err
+	}
+
+	var volumes []appsodyv1alpha1.VolumeStatus
+	var reason string
+	var rejected []string
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		requested, ok := appsodyutils.GetRequestedStorageSize(cr, template.Name)
+		if !ok {
+			continue
+		}
+
+		for i := range pvcList.Items {
+			pvc := &pvcList.Items[i]
+			if !appsodyutils.IsVolumeClaimOf(pvc, template.Name, statefulSet) {
+				continue
+			}
+
+			current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			switch requested.Cmp(current) {
+			case -1:
+				reason = "ShrinkNotSupported"
+				rejected = append(rejected, fmt.Sprintf("PersistentVolumeClaim %s can't shrink from %s to %s", pvc.Name, current.String(), requested.String()))
+			case 1:
+				allowed, err := r.IsVolumeExpansionAllowed(pvc)
+				if err != nil {
+					return false, err
+				}
+				if !allowed {
+					reason = "ExpansionNotAllowed"
+					rejected = append(rejected, fmt.Sprintf("storage class of PersistentVolumeClaim %s doesn't allow volume expansion", pvc.Name))
+					break
+				}
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+				err = r.GetClient().Update(context.TODO(), pvc)
+				if err != nil {
+					return false, err
+				}
+				log.Info("Requested expansion", "PersistentVolumeClaim", pvc.Name, "Size", requested.String())
+			}
+			volumes = append(volumes, appsodyutils.GetVolumeStatus(pvc, requested))
+		}
+	}
+	cr.Status.Volumes = volumes
+
+	if len(rejected) > 0 {
+		appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeVolumeExpansion, corev1.ConditionFalse, reason, strings.Join(rejected, "; "), &cr.Status)
+		return false, nil
+	}
+	for _, v := range volumes {
+		// Unbound PVCs don't report a capacity yet and are provisioned with the requested size
+		if v.Phase != appsodyv1alpha1.VolumeResizePhaseResized && v.Capacity != "" {
+			appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeVolumeExpansion, corev1.ConditionFalse, string(v.Phase), fmt.Sprintf("PersistentVolumeClaim %s is being resized to %s", v.Name, v.RequestedSize), &cr.Status)
+			return true, nil
+		}
+	}
+	appsodyutils.UpdateCondition(appsodyv1alpha1.StatusConditionTypeVolumeExpansion, corev1.ConditionTrue, "", "", &cr.Status)
+	return false, nil
+}
+
+// getServedConfigMaps returns the ConfigMaps referred to by the Knative revisions receiving the traffic of the
+// application. Returns false while the revisions receiving traffic are not known yet.
+func (r *ReconcileAppsodyApplication) getServedConfigMaps(cr *appsodyv1alpha1.AppsodyApplication, knativeVersion string) ([]string, bool, error) {
+	if cr.Status.Knative == nil || len(cr.Status.Knative.Traffic) == 0 {
+		return nil, false, nil
+	}
+	served := []string{}
+	for _, t := range cr.Status.Knative.Traffic {
+		rev := appsodyutils.NewKnativeRevision(knativeVersion, metav1.ObjectMeta{Name: t.RevisionName, Namespace: cr.Namespace})
+		err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: t.RevisionName, Namespace: cr.Namespace}, rev)
+		if errors.IsNotFound(err) {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+		names, err := appsodyutils.GetKnativeRevisionConfigMaps(rev)
+		if err != nil {
+			return nil, false, err
+		}
+		served = append(served, names...)
+	}
+	return served, true, nil
+}
+
+// deleteStaleConfigMaps deletes the ConfigMaps rendered from earlier versions of the configuration of the application
+// except the ones to keep
+func (r *ReconcileAppsodyApplication) deleteStaleConfigMaps(cr *appsodyv1alpha1.AppsodyApplication, keep ...string) error {
+	configMaps := &corev1.ConfigMapList{}
+	opts := client.InNamespace(cr.Namespace).MatchingLabels(map[string]string{appsodyutils.ConfigOfLabel: cr.Name})
+	err := r.GetClient().List(context.TODO(), opts, configMaps)
+	if err != nil {
+		return err
+	}
+	kept := map[string]bool{}
+	for _, name := range keep {
+		kept[name] = true
+	}
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if !kept[configMap.Name] && configMap.Labels[appsodyutils.ConfigOfLabel] == cr.Name && metav1.IsControlledBy(configMap, cr) {
+			err = r.DeleteResource(configMap)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
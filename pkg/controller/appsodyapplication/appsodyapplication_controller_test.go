@@ -2,17 +2,38 @@ package appsodyapplication
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
 	appsodyutils "github.com/appsody-operator/pkg/utils"
+	imagename "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	imagev1 "github.com/openshift/api/image/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/discovery"
@@ -21,7 +42,9 @@ import (
 	"k8s.io/client-go/rest"
 	coretesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
@@ -43,10 +66,19 @@ var (
 	volumeCT                   = &corev1.PersistentVolumeClaim{TypeMeta: metav1.TypeMeta{Kind: "StatefulSet"}}
 	storage                    = appsodyv1alpha1.AppsodyApplicationStorage{Size: "10Mi", MountPath: "/mnt/data", VolumeClaimTemplate: volumeCT}
 	createKnativeService       = true
+	latestRevision             = true
+	revisionSuffix             = "v2"
 	stack                      = "java-microprofile"
 	genStack                   = "generic"
 	statefulSetSN              = name + "-headless"
 	defaultKSVCName            = "user-container"
+	knative                    = &appsodyv1alpha1.AppsodyApplicationKnative{
+		RevisionSuffix: revisionSuffix,
+		Traffic: []appsodyv1alpha1.KnativeTrafficTarget{
+			{RevisionName: name + "-v1", Percent: 90},
+			{Tag: "candidate", LatestRevision: &latestRevision, Percent: 10},
+		},
+	}
 )
 
 type Test struct {
@@ -84,10 +116,11 @@ func TestAppsodyController(t *testing.T) {
 		stack:    {ServiceAccountName: &serviceAccountName, Service: service},
 		genStack: {Service: genService},
 	}
-	constantsMap := map[string]*appsodyv1alpha1.AppsodyApplicationSpec{}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
 
 	// Create a ReconcileAppsodyApplication object
-	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap}
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
 	r.SetDiscoveryClient(createFakeDiscoveryClient())
 
 	// Mock request to simulate Reconcile being called on an event for a watched resource
@@ -147,6 +180,7 @@ func TestAppsodyController(t *testing.T) {
 	appsody.Spec = appsodyv1alpha1.AppsodyApplicationSpec{
 		Stack:                stack,
 		CreateKnativeService: &createKnativeService,
+		Knative:              knative,
 		PullPolicy:           &pullPolicy,
 		ApplicationImage:     ksvcAppImage,
 	}
@@ -178,6 +212,11 @@ func TestAppsodyController(t *testing.T) {
 		{"service image name", ksvcAppImage, ksvc.Spec.Template.Spec.Containers[0].Image},
 		{"pull policy", pullPolicy, ksvc.Spec.Template.Spec.Containers[0].ImagePullPolicy},
 		{"service account name", name, ksvc.Spec.Template.Spec.ServiceAccountName},
+		{"revision name", name + "-" + revisionSuffix, ksvc.Spec.Template.Name},
+		{"traffic targets", 2, len(ksvc.Spec.Traffic)},
+		{"pinned revision", name + "-v1", ksvc.Spec.Traffic[0].RevisionName},
+		{"tagged percent", 10, ksvc.Spec.Traffic[1].Percent},
+		{"tag", "candidate", ksvc.Spec.Traffic[1].Tag},
 	}
 	verifyTests("ksvc", ksvcTests, t)
 
@@ -263,119 +302,1561 @@ func TestAppsodyController(t *testing.T) {
 	}
 }
 
-func TestConfigMapDefaults(t *testing.T) {
+func TestKnativeServingVersions(t *testing.T) {
 	logf.SetLogger(logf.ZapLogger(true))
 
-	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Service: service}
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, CreateKnativeService: &createKnativeService}
 	appsody := createAppsodyApp(name, namespace, spec)
 
-	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	// Service created through the deprecated v1alpha1 `release` mode
+	rolloutPercent := 20
+	existing := &servingv1alpha1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: servingv1alpha1.SchemeGroupVersion.String(), Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: servingv1alpha1.ServiceSpec{
+			DeprecatedRelease: &servingv1alpha1.ReleaseType{
+				Revisions:      []string{name + "-v1", name + "-v2"},
+				RolloutPercent: rolloutPercent,
+				Configuration: servingv1alpha1.ConfigurationSpec{
+					DeprecatedRevisionTemplate: &servingv1alpha1.RevisionTemplateSpec{
+						Spec: servingv1alpha1.RevisionSpec{DeprecatedContainer: &corev1.Container{Image: appImage}},
+					},
+				},
+			},
+		},
+	}
+
+	objs, s := []runtime.Object{appsody, existing}, scheme.Scheme
+	if err := servingv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add servingv1alpha1 scheme: (%v)", err)
+	}
 	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
 	cl := fakeclient.NewFakeClient(objs...)
 
 	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
 	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
-	constantsMap := map[string]*appsodyv1alpha1.AppsodyApplicationSpec{}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
 
-	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap}
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
 	r.SetDiscoveryClient(createFakeDiscoveryClient())
 
-	// Create request for defaults case
-	req := createReconcileRequest("appsody-operator", namespace)
+	req := createReconcileRequest(name, namespace)
+	res, err := r.Reconcile(req)
+	verifyReconcile(res, err, t)
 
-	// Create configMap for defaults case
-	data := map[string]string{stack: `{"expose":true}`}
-	configMap := createConfigMap("appsody-operator", namespace, data)
-	if err := r.GetClient().Create(context.TODO(), configMap); err != nil {
-		t.Fatalf("Create configMap: (%v)", err)
+	ksvc := &servingv1alpha1.Service{}
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, ksvc); err != nil {
+		t.Fatalf("Get KnativeService: (%v)", err)
+	}
+
+	// The release mode is rewritten into template and traffic without changing the split
+	if len(ksvc.Spec.Traffic) != 3 {
+		t.Fatalf("migrated ksvc expected 3 traffic targets, actual: (%v)", ksvc.Spec.Traffic)
+	}
+	migrationTests := []Test{
+		{"release removed", true, ksvc.Spec.DeprecatedRelease == nil},
+		{"current revision", name + "-v1", ksvc.Spec.Traffic[0].RevisionName},
+		{"current percent", 100 - rolloutPercent, ksvc.Spec.Traffic[0].Percent},
+		{"candidate revision", name + "-v2", ksvc.Spec.Traffic[1].RevisionName},
+		{"candidate percent", rolloutPercent, ksvc.Spec.Traffic[1].Percent},
+		{"image", appsody.Spec.ApplicationImage, ksvc.Spec.Template.Spec.Containers[0].Image},
+	}
+	verifyTests("migrated ksvc", migrationTests, t)
+
+	// Prefer serving.knative.dev/v1 once the cluster serves it
+	fakeDiscovery := createFakeDiscoveryClient().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.Resources = append(fakeDiscovery.Resources, &metav1.APIResourceList{
+		GroupVersion: "serving.knative.dev/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "services", Namespaced: true, Kind: "Service", SingularName: "service"},
+		},
+	})
+	r.SetDiscoveryClient(fakeDiscovery)
+
+	res, err = r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	ksvcV1 := &unstructured.Unstructured{}
+	ksvcV1.SetAPIVersion("serving.knative.dev/v1")
+	ksvcV1.SetKind("Service")
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, ksvcV1); err != nil {
+		t.Fatalf("Get v1 KnativeService: (%v)", err)
+	}
+
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+
+	revisionName, _, _ := unstructured.NestedString(ksvcV1.Object, "spec", "template", "metadata", "name")
+	v1Tests := []Test{
+		{"api version", "serving.knative.dev/v1", ksvcV1.GetAPIVersion()},
+		{"revision name", appsodyutils.GetKnativeRevisionName(appsody), revisionName},
+	}
+	verifyTests("v1 ksvc", v1Tests, t)
+}
+
+func TestVolumeExpansion(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:   stack,
+		Storage: &appsodyv1alpha1.AppsodyApplicationStorage{Size: "1Gi", MountPath: "/mnt/data"},
+	}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	allowExpansion := true
+	storageClassName := "expandable"
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: storageClassName},
+		AllowVolumeExpansion: &allowExpansion,
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-" + name + "-0", Namespace: namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+		},
 	}
 
+	// The claims of another application whose name starts with the name of the application are left alone
+	other := createAppsodyApp(name+"-x", namespace, spec)
+	otherPVC := pvc.DeepCopy()
+	otherPVC.Name = "pvc-" + name + "-x-0"
+
+	objs, s := []runtime.Object{appsody, storageClass, pvc, other, otherPVC}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+	r.SetAPIReader(cl)
+
+	req := createReconcileRequest(name, namespace)
 	res, err := r.Reconcile(req)
 	verifyReconcile(res, err, t)
 
-	// Update request name
-	req = createReconcileRequest(name, namespace)
+	// Grow the volume
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+	appsody.Spec.Storage.Size = "2Gi"
+	updateAppsody(r, appsody, t)
+
 	res, err = r.Reconcile(req)
 	if err != nil {
 		t.Fatalf("reconcile: (%v)", err)
 	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not requeue while the volume is resized")
+	}
 
+	if err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: pvc.Name, Namespace: namespace}, pvc); err != nil {
+		t.Fatalf("Get PersistentVolumeClaim: (%v)", err)
+	}
 	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
 		t.Fatalf("Get appsody: (%v)", err)
 	}
+	if err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: otherPVC.Name, Namespace: namespace}, otherPVC); err != nil {
+		t.Fatalf("Get PersistentVolumeClaim: (%v)", err)
+	}
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	otherRequested := otherPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+	expandTests := []Test{
+		{"pvc size", "2Gi", requested.String()},
+		{"other application pvc size", "1Gi", otherRequested.String()},
+		{"volume status", 1, len(appsody.Status.Volumes)},
+		{"volume phase", appsodyv1alpha1.VolumeResizePhasePending, appsody.Status.Volumes[0].Phase},
+		{"condition", corev1.ConditionFalse, appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeVolumeExpansion, &appsody.Status).Status},
+	}
+	verifyTests("expand", expandTests, t)
 
-	// Check updated values in appsody
-	configMapDefTests := []Test{{"expose", true, *appsody.Spec.Expose}}
-	verifyTests("configMapDefaults", configMapDefTests, t)
+	// Shrinking is rejected and leaves the PVC alone
+	appsody.Spec.Storage.Size = "512Mi"
+	updateAppsody(r, appsody, t)
+
+	res, err = r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	if err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: pvc.Name, Namespace: namespace}, pvc); err != nil {
+		t.Fatalf("Get PersistentVolumeClaim: (%v)", err)
+	}
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+	requested = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	shrinkTests := []Test{
+		{"pvc size", "2Gi", requested.String()},
+		{"condition reason", "ShrinkNotSupported", appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeVolumeExpansion, &appsody.Status).Reason},
+	}
+	verifyTests("shrink", shrinkTests, t)
 }
 
-func TestConfigMapConstants(t *testing.T) {
+func TestPatchServiceAccount(t *testing.T) {
 	logf.SetLogger(logf.ZapLogger(true))
 
-	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack}
+	userServiceAccountName, pullSecret, patch := "custom", "legacy", true
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:               stack,
+		ServiceAccountName:  &userServiceAccountName,
+		PatchServiceAccount: &patch,
+		PullSecret:          &pullSecret,
+		PullSecrets:         []string{"registry-a", "legacy", "registry-b"},
+	}
 	appsody := createAppsodyApp(name, namespace, spec)
+	userServiceAccount := &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: userServiceAccountName, Namespace: namespace},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "dockercfg"}},
+	}
 
-	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	objs, s := []runtime.Object{appsody, userServiceAccount}, scheme.Scheme
 	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
 	cl := fakeclient.NewFakeClient(objs...)
 
 	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
 	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
-	constantsMap := map[string]*appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
 
-	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap}
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
 	r.SetDiscoveryClient(createFakeDiscoveryClient())
 
-	// Create request for constants case
-	req := createReconcileRequest("appsody-operator-constants", namespace)
+	req := createReconcileRequest(name, namespace)
+	res, err := r.Reconcile(req)
+	verifyReconcile(res, err, t)
 
-	// Expose enabled and port updated to 3000
-	data := map[string]string{stack: `{"expose":true, "service":{"port": 3000,"type": "ClusterIP"}}`}
-	configMap := createConfigMap("appsody-operator-constants", namespace, data)
+	if err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: userServiceAccountName, Namespace: namespace}, userServiceAccount); err != nil {
+		t.Fatalf("Get ServiceAccount: (%v)", err)
+	}
+	dep := &appsv1.Deployment{}
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, dep); err != nil {
+		t.Fatalf("Get Deployment: (%v)", err)
+	}
 
-	if err := r.GetClient().Create(context.TODO(), configMap); err != nil {
-		t.Fatalf("Create configMap: (%v)", err)
+	secretNames := func(secrets []corev1.LocalObjectReference) string {
+		names := []string{}
+		for _, secret := range secrets {
+			names = append(names, secret.Name)
+		}
+		return strings.Join(names, ",")
 	}
+	patchTests := []Test{
+		{"service account pull secrets", "dockercfg,legacy,registry-a,registry-b", secretNames(userServiceAccount.ImagePullSecrets)},
+		{"service account not owned", 0, len(userServiceAccount.OwnerReferences)},
+		{"pod pull secrets", "legacy,registry-a,registry-b", secretNames(dep.Spec.Template.Spec.ImagePullSecrets)},
+	}
+	verifyTests("patchServiceAccount", patchTests, t)
+}
 
-	res, err := r.Reconcile(req)
-	verifyReconcile(res, err, t)
+func TestConfigRollout(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
 
-	// Update reconcile request name
-	req = createReconcileRequest(name, namespace)
-	_, err = r.Reconcile(req)
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack: stack,
+		EnvFrom: []corev1.EnvFromSource{
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}}},
+		},
+		Volumes: []corev1.Volume{
+			{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "app-creds"}}},
+		},
+	}
+	appsody := createAppsodyApp(name, namespace, spec)
+	configMap := createConfigMap("app-config", namespace, map[string]string{"LOG_LEVEL": "info"})
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-creds", Namespace: namespace},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
 
-	if err != nil {
-		t.Fatalf("reconcile: (%v)", err)
+	objs, s := []runtime.Object{appsody, configMap, secret}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody, &appsodyv1alpha1.AppsodyApplicationList{})
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	configHash := func() string {
+		res, err := r.Reconcile(req)
+		verifyReconcile(res, err, t)
+		dep := &appsv1.Deployment{}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, dep); err != nil {
+			t.Fatalf("Get Deployment: (%v)", err)
+		}
+		return dep.Spec.Template.Annotations[appsodyutils.ConfigHashAnnotation]
+	}
+
+	original := configHash()
+
+	configMap.Data["LOG_LEVEL"] = "debug"
+	if err := r.GetClient().Update(context.TODO(), configMap); err != nil {
+		t.Fatalf("Update ConfigMap: (%v)", err)
+	}
+	changed := configHash()
+
+	// Excluded references no longer contribute to the hash
+	if err := r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+	appsody.Spec.RolloutExclusions = []appsodyv1alpha1.ConfigReference{
+		{Kind: appsodyv1alpha1.ConfigReferenceKindConfigMap, Name: "app-config"},
+		{Kind: appsodyv1alpha1.ConfigReferenceKindSecret, Name: "app-creds"},
+	}
+	updateAppsody(r, appsody, t)
+	excluded := configHash()
+
+	mapper := requestsForConfig(cl, appsodyv1alpha1.ConfigReferenceKindSecret)
+	appsody.Spec.RolloutExclusions = nil
+	updateAppsody(r, appsody, t)
+	requests := mapper(handler.MapObject{Meta: secret, Object: secret})
+
+	rolloutTests := []Test{
+		{"hash set", true, original != ""},
+		{"hash changed", true, original != changed},
+		{"excluded", "", excluded},
+		{"mapped requests", 1, len(requests)},
+	}
+	verifyTests("config rollout", rolloutTests, t)
+}
+
+func TestInlineConfig(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack: stack,
+		Config: &appsodyv1alpha1.AppsodyApplicationConfig{
+			Files: []appsodyv1alpha1.AppsodyApplicationConfigFile{
+				{Path: "/config/server.xml", Content: "<server/>"},
+				{Path: "/config/keystore.jks", SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "keystore"},
+					Key:                  "keystore.jks",
+				}},
+			},
+			Env: map[string]string{"LOG_LEVEL": "info"},
+		},
 	}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	res, err := r.Reconcile(req)
+	verifyReconcile(res, err, t)
 
 	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
 		t.Fatalf("Get appsody: (%v)", err)
 	}
+	original := appsodyutils.GetConfigMapName(appsody)
+	configMap := &corev1.ConfigMap{}
+	if err = r.GetClient().Get(context.TODO(), types.NamespacedName{Name: original, Namespace: namespace}, configMap); err != nil {
+		t.Fatalf("Get ConfigMap: (%v)", err)
+	}
+	dep := &appsv1.Deployment{}
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, dep); err != nil {
+		t.Fatalf("Get Deployment: (%v)", err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	volume := dep.Spec.Template.Spec.Volumes[0]
+	configTests := []Test{
+		{"file content", "<server/>", configMap.Data["file.0"]},
+		{"env content", "info", configMap.Data["env.LOG_LEVEL"]},
+		{"volume", appsodyutils.ConfigVolumeName, volume.Name},
+		{"inline source", original, volume.Projected.Sources[0].ConfigMap.Name},
+		{"secret source", "keystore", volume.Projected.Sources[1].Secret.Name},
+		{"mounts", 2, len(container.VolumeMounts)},
+		{"file mount", "/config/keystore.jks", container.VolumeMounts[1].MountPath},
+		{"file sub path", "file.1", container.VolumeMounts[1].SubPath},
+		{"env", original, container.Env[0].ValueFrom.ConfigMapKeyRef.Name},
+	}
+	verifyTests("inline config", configTests, t)
 
-	configMapConstTests := []Test{
-		{"expose", true, *appsody.Spec.Expose},
-		{"service port", int32(3000), appsody.Spec.Service.Port},
+	// Changing the content renders a new ConfigMap, and the outdated one is kept while pods of the
+	// previous version may still mount it
+	appsody.Spec.Config.Files[0].Content = "<server description=\"new\"/>"
+	updateAppsody(r, appsody, t)
+	res, err = r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, dep); err != nil {
+		t.Fatalf("Get Deployment: (%v)", err)
 	}
-	verifyTests("configMapConstants", configMapConstTests, t)
+	renamed := dep.Spec.Template.Spec.Volumes[0].Projected.Sources[0].ConfigMap.Name
+	rollingTests := []Test{
+		{"renamed", true, renamed != original},
+		{"outdated kept during rollout", nil, r.GetClient().Get(context.TODO(), types.NamespacedName{Name: original, Namespace: namespace}, &corev1.ConfigMap{})},
+	}
+	verifyTests("inline config rollout", rollingTests, t)
+
+	// The outdated ConfigMap is removed once the rollout completes
+	dep.Status = appsv1.DeploymentStatus{ObservedGeneration: dep.Generation, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+	if err = r.GetClient().Status().Update(context.TODO(), dep); err != nil {
+		t.Fatalf("Update Deployment status: (%v)", err)
+	}
+	res, err = r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	configMaps := &corev1.ConfigMapList{}
+	if err = r.GetClient().List(context.TODO(), &client.ListOptions{Namespace: namespace}, configMaps); err != nil {
+		t.Fatalf("List ConfigMaps: (%v)", err)
+	}
+	updateTests := []Test{
+		{"config maps", 1, len(configMaps.Items)},
+		{"mounted", renamed, configMaps.Items[0].Name},
+	}
+	verifyTests("inline config update", updateTests, t)
 }
 
-// Helper Functions
-func createAppsodyApp(n, ns string, spec appsodyv1alpha1.AppsodyApplicationSpec) *appsodyv1alpha1.AppsodyApplication {
-	app := &appsodyv1alpha1.AppsodyApplication{
-		ObjectMeta: metav1.ObjectMeta{Name: n, Namespace: ns},
-		Spec:       spec,
-		Status:     status,
+func TestKnativeInlineConfig(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:                stack,
+		ApplicationImage:     appImage,
+		CreateKnativeService: &createKnativeService,
+		Config: &appsodyv1alpha1.AppsodyApplicationConfig{
+			Files: []appsodyv1alpha1.AppsodyApplicationConfigFile{{Path: "/config/server.xml", Content: "<server/>"}},
+			Env:   map[string]string{"LOG_LEVEL": "info"},
+		},
 	}
-	return app
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	if err := servingv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add servingv1alpha1 scheme: (%v)", err)
+	}
+	if err := routev1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add route scheme: (%v)", err)
+	}
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	reconcileConfig := func(content string) {
+		if content != "" {
+			appsody.Spec.Config.Files[0].Content = content
+			updateAppsody(r, appsody, t)
+		}
+		res, err := r.Reconcile(req)
+		verifyReconcile(res, err, t)
+		*appsody = appsodyv1alpha1.AppsodyApplication{}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+	}
+	// serveRevision creates a revision from the current template of the Knative Service and routes the traffic to it
+	serveRevision := func(revisionName string) {
+		ksvc := &servingv1alpha1.Service{}
+		if err := r.GetClient().Get(context.TODO(), req.NamespacedName, ksvc); err != nil {
+			t.Fatalf("Get KnativeService: (%v)", err)
+		}
+		rev := &servingv1alpha1.Revision{
+			TypeMeta:   metav1.TypeMeta{APIVersion: servingv1alpha1.SchemeGroupVersion.String(), Kind: "Revision"},
+			ObjectMeta: metav1.ObjectMeta{Name: revisionName, Namespace: namespace},
+			Spec:       ksvc.Spec.Template.Spec,
+		}
+		if err := r.GetClient().Create(context.TODO(), rev); err != nil {
+			t.Fatalf("Create Revision: (%v)", err)
+		}
+		target := servingv1alpha1.TrafficTarget{}
+		target.RevisionName, target.Percent = revisionName, 100
+		ksvc.Status.Traffic = []servingv1alpha1.TrafficTarget{target}
+		if err := r.GetClient().Update(context.TODO(), ksvc); err != nil {
+			t.Fatalf("Update KnativeService: (%v)", err)
+		}
+	}
+	configMapNames := func() []string {
+		configMaps := &corev1.ConfigMapList{}
+		if err := r.GetClient().List(context.TODO(), &client.ListOptions{Namespace: namespace}, configMaps); err != nil {
+			t.Fatalf("List ConfigMaps: (%v)", err)
+		}
+		names := []string{}
+		for _, cm := range configMaps.Items {
+			names = append(names, cm.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	reconcileConfig("")
+	first := appsodyutils.GetConfigMapName(appsody)
+	serveRevision("rev-1")
+
+	// ConfigMaps of revisions no longer receiving traffic are deleted, while the served revision keeps its own
+	reconcileConfig("<server description=\"second\"/>")
+	reconcileConfig("<server description=\"third\"/>")
+	third := appsodyutils.GetConfigMapName(appsody)
+	expected := []string{first, third}
+	sort.Strings(expected)
+	verifyTests("knative config", []Test{{"config maps", strings.Join(expected, ","), strings.Join(configMapNames(), ",")}}, t)
+
+	serveRevision("rev-3")
+	reconcileConfig("")
+	verifyTests("knative config served", []Test{{"config maps", third, strings.Join(configMapNames(), ",")}}, t)
 }
 
-func createFakeDiscoveryClient() discovery.DiscoveryInterface {
-	fakeDiscoveryClient := &fakediscovery.FakeDiscovery{Fake: &coretesting.Fake{}}
-	fakeDiscoveryClient.Resources = []*metav1.APIResourceList{
-		{
-			GroupVersion: routev1.SchemeGroupVersion.String(),
-			APIResources: []metav1.APIResource{
-				{Name: "routes", Namespaced: true, Kind: "Route"},
+func TestHooks(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	backoffLimit := int32(2)
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:            stack,
+		ApplicationImage: appImage,
+		Hooks: &appsodyv1alpha1.AppsodyApplicationHooks{
+			PreDeploy:  &appsodyv1alpha1.AppsodyApplicationHook{Command: []string{"migrate"}, BackoffLimit: &backoffLimit},
+			PostDeploy: &appsodyv1alpha1.AppsodyApplicationHook{Command: []string{"smoke-test"}},
+		},
+	}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	reconcilePending := func() {
+		res, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if res.RequeueAfter == 0 {
+			t.Error("reconcile did not requeue while hooks are pending")
+		}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+	}
+	getJob := func(name string) *batchv1.Job {
+		job := &batchv1.Job{}
+		if err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, job); err != nil {
+			t.Fatalf("Get Job: (%v)", err)
+		}
+		return job
+	}
+
+	// The Deployment is not created until the preDeploy hook succeeds
+	reconcilePending()
+	preJob := getJob(appsody.Status.Hooks[0].JobName)
+	dep := &appsv1.Deployment{}
+	depErr := r.GetClient().Get(context.TODO(), req.NamespacedName, dep)
+	preTests := []Test{
+		{"pre phase", appsodyv1alpha1.HookPhaseRunning, appsody.Status.Hooks[0].Phase},
+		{"pre logs", "job-name=" + preJob.Name, appsody.Status.Hooks[0].LogsSelector},
+		{"pre backoff", backoffLimit, *appsody.Status.Hooks[0].BackoffLimit},
+		{"pre image", appImage, preJob.Spec.Template.Spec.Containers[0].Image},
+		{"pre command", "migrate", preJob.Spec.Template.Spec.Containers[0].Command[0]},
+		{"pre restart", corev1.RestartPolicyNever, preJob.Spec.Template.Spec.RestartPolicy},
+		{"pre not selected by service", "", preJob.Spec.Template.Labels["app.kubernetes.io/name"]},
+		{"deployment gated", true, errors.IsNotFound(depErr)},
+	}
+	verifyTests("preDeploy", preTests, t)
+
+	// The postDeploy hook waits for the rollout of the Deployment
+	preJob.Status.Succeeded = 1
+	if err := r.GetClient().Update(context.TODO(), preJob); err != nil {
+		t.Fatalf("Update Job: (%v)", err)
+	}
+	reconcilePending()
+	if err := r.GetClient().Get(context.TODO(), req.NamespacedName, dep); err != nil {
+		t.Fatalf("Get Deployment: (%v)", err)
+	}
+	waitTests := []Test{
+		{"pre phase", appsodyv1alpha1.HookPhaseSucceeded, appsody.Status.Hooks[0].Phase},
+		{"post phase", appsodyv1alpha1.HookPhaseWaiting, appsody.Status.Hooks[1].Phase},
+	}
+	verifyTests("postDeploy waiting", waitTests, t)
+
+	dep.Status.UpdatedReplicas, dep.Status.AvailableReplicas = 1, 1
+	if err := r.GetClient().Update(context.TODO(), dep); err != nil {
+		t.Fatalf("Update Deployment: (%v)", err)
+	}
+	reconcilePending()
+	postJob := getJob(appsody.Status.Hooks[1].JobName)
+	postTests := []Test{
+		{"post phase", appsodyv1alpha1.HookPhaseRunning, appsody.Status.Hooks[1].Phase},
+		{"post command", "smoke-test", postJob.Spec.Template.Spec.Containers[0].Command[0]},
+	}
+	verifyTests("postDeploy", postTests, t)
+
+	// A new image runs a new preDeploy Job and removes the previous one
+	appsody.Spec.ApplicationImage = ksvcAppImage
+	updateAppsody(r, appsody, t)
+	reconcilePending()
+	jobs := &batchv1.JobList{}
+	if err := r.GetClient().List(context.TODO(), &client.ListOptions{Namespace: namespace}, jobs); err != nil {
+		t.Fatalf("List Jobs: (%v)", err)
+	}
+	rerunTests := []Test{
+		{"new pre job", true, appsody.Status.Hooks[0].JobName != preJob.Name},
+		{"pre phase", appsodyv1alpha1.HookPhaseRunning, appsody.Status.Hooks[0].Phase},
+		{"jobs", 2, len(jobs.Items)},
+	}
+	verifyTests("preDeploy rerun", rerunTests, t)
+}
+
+func TestWorkloadKind(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	res, err := r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	exists := func(obj runtime.Object) bool {
+		err := r.GetClient().Get(context.TODO(), req.NamespacedName, obj)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Fatalf("Get %T: (%v)", obj, err)
+		}
+		return err == nil
+	}
+
+	// Switching to a DaemonSet removes the Deployment
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+	appsody.Spec.WorkloadKind = appsodyv1alpha1.WorkloadKindDaemonSet
+	updateAppsody(r, appsody, t)
+	res, err = r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	daemonSetTests := []Test{
+		{"daemonset", true, exists(&appsv1.DaemonSet{})},
+		{"deployment", false, exists(&appsv1.Deployment{})},
+		{"service", true, exists(&corev1.Service{})},
+	}
+	verifyTests("daemonset", daemonSetTests, t)
+
+	// CronJobs require a schedule and don't receive traffic
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+	appsody.Spec.WorkloadKind = appsodyv1alpha1.WorkloadKindCronJob
+	updateAppsody(r, appsody, t)
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+	noScheduleTests := []Test{
+		{"reconciled", corev1.ConditionFalse, appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeReconciled, &appsody.Status).Status},
+		{"daemonset kept", true, exists(&appsv1.DaemonSet{})},
+	}
+	verifyTests("cronjob without schedule", noScheduleTests, t)
+
+	appsody.Spec.Schedule = "*/5 * * * *"
+	updateAppsody(r, appsody, t)
+	res, err = r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	cronJob := &batchv1beta1.CronJob{}
+	cronJobTests := []Test{
+		{"cronjob", true, exists(cronJob)},
+		{"schedule", "*/5 * * * *", cronJob.Spec.Schedule},
+		{"restart policy", corev1.RestartPolicyOnFailure, cronJob.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy},
+		{"daemonset", false, exists(&appsv1.DaemonSet{})},
+		{"service", false, exists(&corev1.Service{})},
+	}
+	verifyTests("cronjob", cronJobTests, t)
+}
+
+func TestUnknownStackPolicy(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	defer os.Unsetenv(appsodyutils.UnknownStackPolicyEnvVar)
+	defer os.Unsetenv(appsodyutils.AllowedStacksEnvVar)
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: "java-microprofle"}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{"generic": {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	reconcileWith := func(policy, allowed string) {
+		os.Setenv(appsodyutils.UnknownStackPolicyEnvVar, policy)
+		os.Setenv(appsodyutils.AllowedStacksEnvVar, allowed)
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if err := r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+	}
+	condition := func(conditionType appsodyv1alpha1.StatusConditionType) corev1.ConditionStatus {
+		if c := appsodyutils.GetCondition(conditionType, &appsody.Status); c != nil {
+			return c.Status
+		}
+		return ""
+	}
+
+	// Unknown stacks fall back to the generic defaults by default
+	reconcileWith("", "")
+	fallbackTests := []Test{
+		{"reconciled", corev1.ConditionTrue, condition(appsodyv1alpha1.StatusConditionTypeReconciled)},
+		{"stack recognized", corev1.ConditionFalse, condition(appsodyv1alpha1.StatusConditionTypeStackRecognized)},
+		{"generic port", service.Port, appsody.Spec.Service.Port},
+	}
+	verifyTests("fallback", fallbackTests, t)
+
+	reconcileWith(string(appsodyutils.UnknownStackPolicyStrict), "")
+	verifyTests("strict", []Test{{"reconciled", corev1.ConditionFalse, condition(appsodyv1alpha1.StatusConditionTypeReconciled)}}, t)
+
+	reconcileWith(string(appsodyutils.UnknownStackPolicyAllowlist), "nodejs, java-microprofile")
+	verifyTests("allowlist", []Test{{"reconciled", corev1.ConditionFalse, condition(appsodyv1alpha1.StatusConditionTypeReconciled)}}, t)
+
+	reconcileWith(string(appsodyutils.UnknownStackPolicyAllowlist), "nodejs, java-microprofle")
+	verifyTests("allowlist", []Test{{"reconciled", corev1.ConditionTrue, condition(appsodyv1alpha1.StatusConditionTypeReconciled)}}, t)
+
+	// The condition is removed once the stack has defaults
+	defaultsMap["java-microprofle"] = appsodyv1alpha1.AppsodyApplicationSpec{Service: service}
+	reconcileWith(string(appsodyutils.UnknownStackPolicyStrict), "")
+	knownTests := []Test{
+		{"reconciled", corev1.ConditionTrue, condition(appsodyv1alpha1.StatusConditionTypeReconciled)},
+		{"stack recognized", corev1.ConditionStatus(""), condition(appsodyv1alpha1.StatusConditionTypeStackRecognized)},
+	}
+	verifyTests("known stack", knownTests, t)
+}
+
+func TestImagePolicy(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:            stack,
+		Service:          service,
+		ApplicationImage: appImage,
+		ImagePolicy:      &appsodyv1alpha1.AppsodyApplicationImagePolicy{PollInterval: "2m", ImageStreamTag: "my-app:latest"},
+	}
+	appsody := createAppsodyApp(name, namespace, spec)
+	digest := "sha256:" + strings.Repeat("a", 64)
+	is := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: namespace},
+		Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{{
+			Tag:   "latest",
+			Items: []imagev1.TagEvent{{DockerImageReference: "registry.example.com/my-app@" + digest, Image: digest}},
+		}}},
+	}
+
+	objs, s := []runtime.Object{appsody, is}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	if err := imagev1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add image scheme: (%v)", err)
+	}
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	deploy := &appsv1.Deployment{}
+	reconcileApp := func() reconcile.Result {
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		// Decoding into a fresh object drops the fields removed from the status
+		*appsody = appsodyv1alpha1.AppsodyApplication{}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, deploy); err != nil {
+			t.Fatalf("Get Deployment: (%v)", err)
+		}
+		return result
+	}
+
+	// The tag is deployed pinned to the digest of the ImageStream tag, and polled for changes
+	result := reconcileApp()
+	pinnedTests := []Test{
+		{"requeue after", 2 * time.Minute, result.RequeueAfter},
+		{"image", "registry.example.com/my-app@" + digest, deploy.Spec.Template.Spec.Containers[0].Image},
+		{"history", 1, len(appsody.Status.ImageDigests)},
+		{"digest", digest, appsody.Status.ImageDigests[0].Digest},
+		{"source image", appImage, appsody.Status.ImageDigests[0].Image},
+	}
+	verifyTests("pinned", pinnedTests, t)
+
+	// Moving the tag rolls out its new digest
+	newDigest := "sha256:" + strings.Repeat("b", 64)
+	is.Status.Tags[0].Items = append([]imagev1.TagEvent{{DockerImageReference: "registry.example.com/my-app@" + newDigest, Image: newDigest}}, is.Status.Tags[0].Items...)
+	if err := r.GetClient().Update(context.TODO(), is); err != nil {
+		t.Fatalf("Update ImageStream: (%v)", err)
+	}
+	reconcileApp()
+	reconcileApp()
+	movedTests := []Test{
+		{"image", "registry.example.com/my-app@" + newDigest, deploy.Spec.Template.Spec.Containers[0].Image},
+		{"history", 2, len(appsody.Status.ImageDigests)},
+		{"previous digest", digest, appsody.Status.ImageDigests[0].Digest},
+		{"digest", newDigest, appsody.Status.ImageDigests[1].Digest},
+	}
+	verifyTests("moved", movedTests, t)
+
+	// Without a policy the image is deployed as is
+	appsody.Spec.ImagePolicy = nil
+	updateAppsody(r, appsody, t)
+	result = reconcileApp()
+	unpinnedTests := []Test{
+		{"requeue after", time.Duration(0), result.RequeueAfter},
+		{"image", appImage, deploy.Spec.Template.Spec.Containers[0].Image},
+		{"history", 0, len(appsody.Status.ImageDigests)},
+	}
+	verifyTests("unpinned", unpinnedTests, t)
+}
+
+func TestImageInspection(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	// In-process registry, unavailable until the image is pushed
+	available := false
+	handler := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available && r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	image := strings.TrimPrefix(server.URL, "http://") + "/app:1.0"
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, ApplicationImage: image}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	if err := routev1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add route scheme: (%v)", err)
+	}
+	cl := &statusSubresourceClient{fakeclient.NewFakeClient(objs...)}
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: &appsodyv1alpha1.AppsodyApplicationService{Type: &serviceType}}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+	r.SetImageInspector(appsodyutils.NewImageInspector(nil))
+
+	req := createReconcileRequest(name, namespace)
+	deploy := &appsv1.Deployment{}
+	reconcileApp := func() reconcile.Result {
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*appsody = appsodyv1alpha1.AppsodyApplication{}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, deploy); err != nil {
+			t.Fatalf("Get Deployment: (%v)", err)
+		}
+		return result
+	}
+
+	// The default port is deployed but not saved while the registry can't be reached
+	result := reconcileApp()
+	unavailableTests := []Test{
+		{"saved port", int32(0), appsody.Spec.Service.Port},
+		{"deployed port", int32(8080), deploy.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort},
+		{"retried", true, result.RequeueAfter > 0 && result.RequeueAfter <= 30*time.Second},
+	}
+	verifyTests("registry unavailable", unavailableTests, t)
+
+	// Once the registry is back, the port of the image is detected and saved
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatalf("Create image: (%v)", err)
+	}
+	if img, err = mutate.Config(img, v1.Config{ExposedPorts: map[string]struct{}{"9443/tcp": {}}}); err != nil {
+		t.Fatalf("Configure image: (%v)", err)
+	}
+	ref, err := imagename.ParseReference(image, imagename.WeakValidation)
+	if err != nil {
+		t.Fatalf("Parse reference: (%v)", err)
+	}
+	available = true
+	if err = remote.Write(ref, img); err != nil {
+		t.Fatalf("Push image: (%v)", err)
+	}
+	// A new inspector stands in for the expired backoff
+	r.SetImageInspector(appsodyutils.NewImageInspector(nil))
+	result = reconcileApp()
+	availableTests := []Test{
+		{"saved port", int32(9443), appsody.Spec.Service.Port},
+		{"deployed port", int32(9443), deploy.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort},
+		{"requeue after", time.Duration(0), result.RequeueAfter},
+	}
+	verifyTests("registry available", availableTests, t)
+}
+
+// statusSubresourceClient only updates the status of applications through their status writer, as the API server
+// does for the status subresource, where the fake client updates the whole object
+// namespacedClient emulates the cache of an operator watching a single namespace: objects of other namespaces are
+// written to the API server but can't be read back
+type namespacedClient struct {
+	client.Client
+	namespace string
+}
+
+func (c *namespacedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if key.Namespace != c.namespace {
+		return errors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+type statusSubresourceClient struct {
+	client.Client
+}
+
+func (c *statusSubresourceClient) Status() client.StatusWriter {
+	return &applicationStatusWriter{c.Client}
+}
+
+type applicationStatusWriter struct {
+	client.Client
+}
+
+func (w *applicationStatusWriter) Update(ctx context.Context, obj runtime.Object) error {
+	app, ok := obj.(*appsodyv1alpha1.AppsodyApplication)
+	if !ok {
+		return w.Client.Status().Update(ctx, obj)
+	}
+	current := &appsodyv1alpha1.AppsodyApplication{}
+	if err := w.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, current); err != nil {
+		return err
+	}
+	current.Status = app.Status
+	if err := w.Client.Update(ctx, current); err != nil {
+		return err
+	}
+	app.ResourceVersion = current.ResourceVersion
+	return nil
+}
+
+func TestArchitectureSupport(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Service: service, Architecture: []string{"arm64"}}
+	appsody := createAppsodyApp(name, namespace, spec)
+	appsody.Status.ImageArchitectures = []string{"amd64", "ppc64le"}
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	reconcileApp := func() {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*appsody = appsodyv1alpha1.AppsodyApplication{}
+		if err := r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+	}
+	condition := func(conditionType appsodyv1alpha1.StatusConditionType) corev1.ConditionStatus {
+		if c := appsodyutils.GetCondition(conditionType, &appsody.Status); c != nil {
+			return c.Status
+		}
+		return ""
+	}
+
+	// The image has no arm64 build, so nothing is rolled out
+	reconcileApp()
+	deploy := &appsv1.Deployment{}
+	err := r.GetClient().Get(context.TODO(), req.NamespacedName, deploy)
+	unsupportedTests := []Test{
+		{"reconciled", corev1.ConditionFalse, condition(appsodyv1alpha1.StatusConditionTypeReconciled)},
+		{"architecture supported", corev1.ConditionFalse, condition(appsodyv1alpha1.StatusConditionTypeArchitectureSupported)},
+		{"deployment not found", true, errors.IsNotFound(err)},
+	}
+	verifyTests("unsupported", unsupportedTests, t)
+
+	// Only the architectures of the image are scheduled
+	appsody.Spec.Architecture = []string{"arm64", "amd64"}
+	updateAppsody(r, appsody, t)
+	reconcileApp()
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, deploy); err != nil {
+		t.Fatalf("Get Deployment: (%v)", err)
+	}
+	term := deploy.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	supportedTests := []Test{
+		{"reconciled", corev1.ConditionTrue, condition(appsodyv1alpha1.StatusConditionTypeReconciled)},
+		{"architecture supported", corev1.ConditionStatus(""), condition(appsodyv1alpha1.StatusConditionTypeArchitectureSupported)},
+		{"architectures", 1, len(term.Values)},
+		{"architecture", "amd64", term.Values[0]},
+	}
+	verifyTests("supported", supportedTests, t)
+}
+
+func TestProfiles(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack}
+	appsody := createAppsodyApp(name, namespace, spec)
+	devApp := createAppsodyApp("app-dev", namespace, appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Profile: "dev"})
+	unknownApp := createAppsodyApp("app-unknown", namespace, appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Profile: "test"})
+	offApp := createAppsodyApp("app-off", namespace, appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Profile: "off"})
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{appsodyutils.ProfileLabel: "prod"}}}
+
+	objs, s := []runtime.Object{appsody, devApp, unknownApp, offApp, ns}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	if err := routev1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add route scheme: (%v)", err)
+	}
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultReplicas, defaultKnative := int32(2), true
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {
+		Service:              service,
+		Replicas:             &defaultReplicas,
+		CreateKnativeService: &defaultKnative,
+		ResourceConstraints:  &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}},
+	}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+	r.SetAPIReader(cl)
+
+	// Profiles are loaded from their ConfigMap
+	data := map[string]string{
+		"prod": `{"replicas": 3, "expose": true, "createKnativeService": false, "resourceConstraints": {"limits": {"memory": "1Gi"}}}`,
+		"dev":  `{"replicas": 1, "createKnativeService": false}`,
+		"off":  `{"replicas": 0, "expose": false, "createKnativeService": false}`,
+	}
+	configMap := createConfigMap("appsody-operator-profiles", namespace, data)
+	if err := r.GetClient().Create(context.TODO(), configMap); err != nil {
+		t.Fatalf("Create configMap: (%v)", err)
+	}
+	res, err := r.Reconcile(createReconcileRequest("appsody-operator-profiles", namespace))
+	verifyReconcile(res, err, t)
+
+	reconcileApp := func(app *appsodyv1alpha1.AppsodyApplication) {
+		req := createReconcileRequest(app.Name, namespace)
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		if err := r.GetClient().Get(context.TODO(), req.NamespacedName, app); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+	}
+
+	// The label of the namespace selects the profile, layered onto the stack defaults
+	reconcileApp(appsody)
+	limit := appsody.Spec.ResourceConstraints.Limits[corev1.ResourceMemory]
+	request := appsody.Spec.ResourceConstraints.Requests[corev1.ResourceMemory]
+	prodTests := []Test{
+		{"profiles", 3, len(r.Profiles)},
+		{"replicas", int32(3), *appsody.Spec.Replicas},
+		{"expose", true, *appsody.Spec.Expose},
+		{"knative service", false, *appsody.Spec.CreateKnativeService},
+		{"memory limit", "1Gi", limit.String()},
+		{"memory request", "256Mi", request.String()},
+		{"service port", service.Port, appsody.Spec.Service.Port},
+		{"status profile", "prod", appsody.Status.Profile},
+	}
+	verifyTests("prod", prodTests, t)
+
+	// `profile` takes precedence over the namespace
+	reconcileApp(devApp)
+	devTests := []Test{
+		{"replicas", int32(1), *devApp.Spec.Replicas},
+		{"expose", true, devApp.Spec.Expose == nil},
+		{"status profile", "dev", devApp.Status.Profile},
+	}
+	verifyTests("dev", devTests, t)
+
+	// Zero values set by a profile override the defaults
+	reconcileApp(offApp)
+	offTests := []Test{
+		{"replicas", int32(0), *offApp.Spec.Replicas},
+		{"expose set", true, offApp.Spec.Expose != nil},
+		{"expose", false, offApp.Spec.Expose != nil && *offApp.Spec.Expose},
+		{"knative service", false, *offApp.Spec.CreateKnativeService},
+		{"service port", service.Port, offApp.Spec.Service.Port},
+	}
+	verifyTests("off", offTests, t)
+
+	reconcileApp(unknownApp)
+	reconciled := appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeReconciled, &unknownApp.Status)
+	verifyTests("unknown", []Test{{"reconciled", corev1.ConditionFalse, reconciled.Status}}, t)
+}
+
+func TestConfigMapDefaults(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Service: service}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	// Create request for defaults case
+	req := createReconcileRequest("appsody-operator", namespace)
+
+	// Create configMap for defaults case
+	data := map[string]string{stack: `{"expose":true}`}
+	configMap := createConfigMap("appsody-operator", namespace, data)
+	if err := r.GetClient().Create(context.TODO(), configMap); err != nil {
+		t.Fatalf("Create configMap: (%v)", err)
+	}
+
+	res, err := r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	// Update request name
+	req = createReconcileRequest(name, namespace)
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+
+	// Check updated values in appsody
+	configMapDefTests := []Test{{"expose", true, *appsody.Spec.Expose}}
+	verifyTests("configMapDefaults", configMapDefTests, t)
+}
+
+func TestConfigMapConstants(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{stack: {AppsodyApplicationSpec: appsodyv1alpha1.AppsodyApplicationSpec{Service: service}}}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	// Create request for constants case
+	req := createReconcileRequest("appsody-operator-constants", namespace)
+
+	// Expose enabled and port updated to 3000
+	data := map[string]string{stack: `{"expose":true, "service":{"port": 3000,"type": "ClusterIP"}}`}
+	configMap := createConfigMap("appsody-operator-constants", namespace, data)
+
+	if err := r.GetClient().Create(context.TODO(), configMap); err != nil {
+		t.Fatalf("Create configMap: (%v)", err)
+	}
+
+	res, err := r.Reconcile(req)
+	verifyReconcile(res, err, t)
+
+	// Update reconcile request name
+	req = createReconcileRequest(name, namespace)
+	_, err = r.Reconcile(req)
+
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	if err = r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+		t.Fatalf("Get appsody: (%v)", err)
+	}
+
+	configMapConstTests := []Test{
+		{"expose", true, *appsody.Spec.Expose},
+		{"service port", int32(3000), appsody.Spec.Service.Port},
+	}
+	verifyTests("configMapConstants", configMapConstTests, t)
+}
+
+// Helper Functions
+func TestRevisions(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Service: service, ApplicationImage: "my-image:1.0"}
+	appsody := createAppsodyApp(name, namespace, spec)
+
+	objs, s := []runtime.Object{appsody}, scheme.Scheme
+	if err := servingv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add servingv1alpha1 scheme: (%v)", err)
+	}
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, appsody)
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	req := createReconcileRequest(name, namespace)
+	reconcileApp := func() {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*appsody = appsodyv1alpha1.AppsodyApplication{}
+		if err := r.GetClient().Get(context.TODO(), req.NamespacedName, appsody); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+	}
+	revisionImages := func() string {
+		images := []string{}
+		for _, rev := range appsody.Status.Revisions {
+			images = append(images, fmt.Sprintf("%d:%s", rev.Revision, rev.Image))
+		}
+		return strings.Join(images, ",")
+	}
+	rollbackTo := func(revision int64) {
+		appsody.Spec.RollbackTo = &revision
+		updateAppsody(r, appsody, t)
+		reconcileApp()
+	}
+
+	// Every resolved spec is recorded, and reconciling the same spec again doesn't add a revision
+	reconcileApp()
+	reconcileApp()
+	appsody.Spec.ApplicationImage = "my-image:2.0"
+	updateAppsody(r, appsody, t)
+	reconcileApp()
+	revisions := &appsv1.ControllerRevisionList{}
+	if err := r.GetClient().List(context.TODO(), &client.ListOptions{}, revisions); err != nil {
+		t.Fatalf("List ControllerRevisions: (%v)", err)
+	}
+	recordedTests := []Test{
+		{"revisions", "1:my-image:1.0,2:my-image:2.0", revisionImages()},
+		{"controller revisions", 2, len(revisions.Items)},
+		{"revision of", name, revisions.Items[0].Labels[appsodyutils.RevisionOfLabel]},
+		{"owner", true, metav1.IsControlledBy(&revisions.Items[0], appsody)},
+	}
+	verifyTests("recorded", recordedTests, t)
+
+	// Rolling back restores the spec of the revision, which becomes the most recent one
+	rollbackTo(1)
+	deploy := &appsv1.Deployment{}
+	if err := r.GetClient().Get(context.TODO(), req.NamespacedName, deploy); err != nil {
+		t.Fatalf("Get Deployment: (%v)", err)
+	}
+	rollbackTests := []Test{
+		{"image", "my-image:1.0", appsody.Spec.ApplicationImage},
+		{"rollbackTo", true, appsody.Spec.RollbackTo == nil},
+		{"deployment image", "my-image:1.0", deploy.Spec.Template.Spec.Containers[0].Image},
+		{"revisions", "2:my-image:2.0,3:my-image:1.0", revisionImages()},
+	}
+	verifyTests("rollback", rollbackTests, t)
+
+	// Revisions of a StatefulSet and a KnativeService are rolled back the same way
+	appsody.Spec.ApplicationImage = "my-image:3.0"
+	appsody.Spec.Storage = &appsodyv1alpha1.AppsodyApplicationStorage{Size: "10Mi", MountPath: "/mnt/data"}
+	updateAppsody(r, appsody, t)
+	reconcileApp()
+	appsody.Spec.ApplicationImage = "my-image:4.0"
+	appsody.Spec.Storage = nil
+	appsody.Spec.CreateKnativeService = &createKnativeService
+	updateAppsody(r, appsody, t)
+	reconcileApp()
+	rollbackTo(4)
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.GetClient().Get(context.TODO(), req.NamespacedName, statefulSet); err != nil {
+		t.Fatalf("Get StatefulSet: (%v)", err)
+	}
+	statefulSetTests := []Test{
+		{"statefulset image", "my-image:3.0", statefulSet.Spec.Template.Spec.Containers[0].Image},
+		{"revisions", "2:my-image:2.0,3:my-image:1.0,5:my-image:4.0,6:my-image:3.0", revisionImages()},
+	}
+	verifyTests("statefulset rollback", statefulSetTests, t)
+
+	rollbackTo(5)
+	ksvc := &servingv1alpha1.Service{}
+	if err := r.GetClient().Get(context.TODO(), req.NamespacedName, ksvc); err != nil {
+		t.Fatalf("Get KnativeService: (%v)", err)
+	}
+	verifyTests("knative rollback", []Test{{"ksvc image", "my-image:4.0", ksvc.Spec.Template.Spec.Containers[0].Image}}, t)
+
+	// Unknown revisions leave the spec as is
+	rollbackTo(1)
+	unknownTests := []Test{
+		{"image", "my-image:4.0", appsody.Spec.ApplicationImage},
+		{"rollbackTo", true, appsody.Spec.RollbackTo == nil},
+	}
+	verifyTests("unknown revision", unknownTests, t)
+
+	// The oldest revisions over the limit are deleted
+	limit := int32(2)
+	appsody.Spec.RevisionHistoryLimit = &limit
+	updateAppsody(r, appsody, t)
+	reconcileApp()
+	revisions = &appsv1.ControllerRevisionList{}
+	if err := r.GetClient().List(context.TODO(), &client.ListOptions{}, revisions); err != nil {
+		t.Fatalf("List ControllerRevisions: (%v)", err)
+	}
+	limitTests := []Test{
+		{"revisions", "7:my-image:4.0,8:my-image:4.0", revisionImages()},
+		{"controller revisions", 2, len(revisions.Items)},
+	}
+	verifyTests("history limit", limitTests, t)
+}
+
+func TestDependencies(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	api := createAppsodyApp("api", namespace, appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, Service: service})
+	api.Status = appsodyv1alpha1.AppsodyApplicationStatus{}
+	bff := createAppsodyApp("bff", namespace, appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:     stack,
+		Service:   service,
+		DependsOn: []appsodyv1alpha1.AppsodyApplicationDependency{{Name: "api"}},
+	})
+	web := createAppsodyApp("web", "frontend", appsodyv1alpha1.AppsodyApplicationSpec{
+		Stack:     stack,
+		Service:   service,
+		DependsOn: []appsodyv1alpha1.AppsodyApplicationDependency{{Name: "api", Namespace: namespace}},
+	})
+
+	objs, s := []runtime.Object{api, bff, web}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, api, &appsodyv1alpha1.AppsodyApplicationList{})
+	if err := routev1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add route scheme: (%v)", err)
+	}
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(10))
+	defaultsMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{stack: {Service: service}}
+	constantsMap := map[string]*appsodyutils.StackConstants{}
+	profilesMap := map[string]appsodyv1alpha1.AppsodyApplicationSpec{}
+
+	r := &ReconcileAppsodyApplication{rb, defaultsMap, constantsMap, profilesMap}
+	r.SetDiscoveryClient(createFakeDiscoveryClient())
+
+	reconcileApp := func(app *appsodyv1alpha1.AppsodyApplication) reconcile.Result {
+		req := createReconcileRequest(app.Name, app.Namespace)
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*app = appsodyv1alpha1.AppsodyApplication{}
+		if err = r.GetClient().Get(context.TODO(), req.NamespacedName, app); err != nil {
+			t.Fatalf("Get appsody: (%v)", err)
+		}
+		return result
+	}
+	condition := func(app *appsodyv1alpha1.AppsodyApplication, conditionType appsodyv1alpha1.StatusConditionType) string {
+		if c := appsodyutils.GetCondition(conditionType, &app.Status); c != nil {
+			return string(c.Status) + "/" + c.Reason
+		}
+		return ""
+	}
+	getDeployment := func(app *appsodyv1alpha1.AppsodyApplication) *appsv1.Deployment {
+		deploy := &appsv1.Deployment{}
+		if err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, deploy); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			t.Fatalf("Get Deployment: (%v)", err)
+		}
+		return deploy
+	}
+	setAvailableReplicas := func(app *appsodyv1alpha1.AppsodyApplication, replicas int32) {
+		deploy := getDeployment(app)
+		deploy.Status.AvailableReplicas = replicas
+		if err := r.GetClient().Update(context.TODO(), deploy); err != nil {
+			t.Fatalf("Update Deployment: (%v)", err)
+		}
+	}
+
+	// The workload is not created before its dependencies are reconciled
+	result := reconcileApp(bff)
+	waitingTests := []Test{
+		{"deployment", true, getDeployment(bff) == nil},
+		{"waiting", "True/DependenciesNotReady", condition(bff, appsodyv1alpha1.StatusConditionTypeWaitingForDependencies)},
+		{"reconciled", "True/", condition(bff, appsodyv1alpha1.StatusConditionTypeReconciled)},
+		{"requeue after", 10 * time.Second, result.RequeueAfter},
+	}
+	verifyTests("waiting", waitingTests, t)
+
+	// Reconciled dependencies are waited for until their pods are available
+	reconcileApp(api)
+	reconcileApp(bff)
+	verifyTests("unavailable", []Test{{"deployment", true, getDeployment(bff) == nil}}, t)
+
+	setAvailableReplicas(api, 1)
+	reconcileApp(bff)
+	readyTests := []Test{
+		{"deployment", false, getDeployment(bff) == nil},
+		{"waiting", "", condition(bff, appsodyv1alpha1.StatusConditionTypeWaitingForDependencies)},
+	}
+	verifyTests("ready", readyTests, t)
+
+	// Running workloads are kept as is, but workloads scaled to 0 are not scaled up
+	setAvailableReplicas(api, 0)
+	bff.Spec.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+	updateAppsody(r, bff, t)
+	reconcileApp(bff)
+	verifyTests("running", []Test{{"env", 1, len(getDeployment(bff).Spec.Template.Spec.Containers[0].Env)}}, t)
+
+	var zero, three int32 = 0, 3
+	bff.Spec.Replicas = &zero
+	updateAppsody(r, bff, t)
+	reconcileApp(bff)
+	bff.Spec.Replicas = &three
+	updateAppsody(r, bff, t)
+	reconcileApp(bff)
+	scaledTests := []Test{
+		{"replicas", zero, *getDeployment(bff).Spec.Replicas},
+		{"waiting", "True/DependenciesNotReady", condition(bff, appsodyv1alpha1.StatusConditionTypeWaitingForDependencies)},
+	}
+	verifyTests("scaled to 0", scaledTests, t)
+
+	setAvailableReplicas(api, 1)
+	reconcileApp(bff)
+	verifyTests("scaled up", []Test{{"replicas", three, *getDeployment(bff).Spec.Replicas}}, t)
+
+	// Dependencies in namespaces the operator doesn't watch are read from the API server
+	frontend := &ReconcileAppsodyApplication{appsodyutils.NewReconcilerBase(&namespacedClient{cl, "frontend"}, s, &rest.Config{}, record.NewFakeRecorder(10)),
+		defaultsMap, constantsMap, profilesMap}
+	frontend.SetDiscoveryClient(createFakeDiscoveryClient())
+	frontend.SetAPIReader(cl)
+	if _, err := frontend.Reconcile(createReconcileRequest(web.Name, web.Namespace)); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	verifyTests("other namespace", []Test{{"deployment", false, getDeployment(web) == nil}}, t)
+
+	// Applications of other namespaces are mapped to their dependents
+	requests := requestsForDependency(cl)(handler.MapObject{Meta: api, Object: api})
+	mapTests := []Test{
+		{"requests", 2, len(requests)},
+	}
+	verifyTests("map", mapTests, t)
+
+	// Cycles are rejected
+	api.Spec.DependsOn = []appsodyv1alpha1.AppsodyApplicationDependency{{Name: "bff"}}
+	updateAppsody(r, api, t)
+	reconcileApp(api)
+	cycleTests := []Test{
+		{"reconciled", corev1.ConditionFalse, appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeReconciled, &api.Status).Status},
+		{"waiting", "True/DependencyCycle", condition(api, appsodyv1alpha1.StatusConditionTypeWaitingForDependencies)},
+		{"message", "Dependency cycle: appsody/api -> appsody/bff -> appsody/api",
+			appsodyutils.GetCondition(appsodyv1alpha1.StatusConditionTypeReconciled, &api.Status).Message},
+	}
+	verifyTests("cycle", cycleTests, t)
+}
+
+func createAppsodyApp(n, ns string, spec appsodyv1alpha1.AppsodyApplicationSpec) *appsodyv1alpha1.AppsodyApplication {
+	app := &appsodyv1alpha1.AppsodyApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: n, Namespace: ns},
+		Spec:       spec,
+		Status:     status,
+	}
+	return app
+}
+
+func createFakeDiscoveryClient() discovery.DiscoveryInterface {
+	fakeDiscoveryClient := &fakediscovery.FakeDiscovery{Fake: &coretesting.Fake{}}
+	fakeDiscoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: routev1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "routes", Namespaced: true, Kind: "Route"},
+			},
+		},
+		{
+			GroupVersion: imagev1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "imagestreams", Namespaced: true, Kind: "ImageStream"},
 			},
 		},
 		{
@@ -0,0 +1,453 @@
+package appsodyapplicationset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	appsodyutils "github.com/appsody-operator/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_appsodyapplicationset")
+
+// namespacesResyncInterval is the interval between two checks for namespaces matching the generators of a set, as
+// namespaces are not watched, and for the applications of the set in other namespaces, which may not be watched
+const namespacesResyncInterval = time.Minute
+
+// Add creates a new AppsodyApplicationSet Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileAppsodyApplicationSet{ReconcilerBase: appsodyutils.NewReconcilerBase(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig(), mgr.GetRecorder("appsody-operator"))}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	c, err := controller.New("appsodyapplicationset-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	pred := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Ignore updates to CR status in which case metadata.Generation does not change
+			return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+				!reflect.DeepEqual(e.MetaOld.GetDeletionTimestamp(), e.MetaNew.GetDeletionTimestamp())
+		},
+	}
+
+	err = c.Watch(&source.Kind{Type: &appsodyv1alpha1.AppsodyApplicationSet{}}, &handler.EnqueueRequestForObject{}, pred)
+	if err != nil {
+		return err
+	}
+
+	// Restore changed applications, and roll out the next applications once the previous ones are ready
+	err = c.Watch(&source.Kind{Type: &appsodyv1alpha1.AppsodyApplication{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(requestsForApplication),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: requestsForConfigMap(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// requestsForApplication maps an application to the set that generated it
+func requestsForApplication(a handler.MapObject) []reconcile.Request {
+	labels := a.Meta.GetLabels()
+	if labels[appsodyutils.ApplicationSetLabel] == "" {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: labels[appsodyutils.ApplicationSetLabel], Namespace: labels[appsodyutils.ApplicationSetNamespaceLabel]},
+	}}
+}
+
+// requestsForConfigMap maps a ConfigMap to the sets in its namespace that generate applications from its rows
+func requestsForConfigMap(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		sets := &appsodyv1alpha1.AppsodyApplicationSetList{}
+		err := c.List(context.TODO(), &client.ListOptions{Namespace: a.Meta.GetNamespace()}, sets)
+		if err != nil {
+			log.Error(err, "Failed to list AppsodyApplicationSets", "Namespace", a.Meta.GetNamespace())
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, set := range sets.Items {
+			for _, g := range set.Spec.Generators {
+				if g.ConfigMap != nil && g.ConfigMap.Name == a.Meta.GetName() && set.Namespace == a.Meta.GetNamespace() {
+					requests = append(requests, reconcile.Request{
+						NamespacedName: types.NamespacedName{Name: set.Name, Namespace: set.Namespace},
+					})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
+
+// blank assignment to verify that ReconcileAppsodyApplicationSet implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileAppsodyApplicationSet{}
+
+// ReconcileAppsodyApplicationSet reconciles a AppsodyApplicationSet object
+type ReconcileAppsodyApplicationSet struct {
+	appsodyutils.ReconcilerBase
+}
+
+// Reconcile creates, updates and deletes the applications generated from the template of the set
+func (r *ReconcileAppsodyApplicationSet) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling AppsodyApplicationSet")
+
+	set := &appsodyv1alpha1.AppsodyApplicationSet{}
+	err := r.GetClient().Get(context.TODO(), request.NamespacedName, set)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Applications in other namespaces can't be garbage collected with the set
+	if set.DeletionTimestamp != nil {
+		if !hasFinalizer(set) {
+			return reconcile.Result{}, nil
+		}
+		err = r.deleteApplications(set, nil)
+		if err != nil {
+			reqLogger.Error(err, "Failed to delete the applications of AppsodyApplicationSet")
+			return reconcile.Result{}, err
+		}
+		finalizers := []string{}
+		for _, f := range set.Finalizers {
+			if f != appsodyutils.ApplicationSetFinalizer {
+				finalizers = append(finalizers, f)
+			}
+		}
+		set.Finalizers = finalizers
+		return reconcile.Result{}, r.GetClient().Update(context.TODO(), set)
+	}
+	if !hasFinalizer(set) {
+		set.Finalizers = append(set.Finalizers, appsodyutils.ApplicationSetFinalizer)
+		err = r.GetClient().Update(context.TODO(), set)
+		if err != nil {
+			reqLogger.Error(err, "Error updating AppsodyApplicationSet")
+			return reconcile.Result{}, err
+		}
+	}
+
+	status := set.Status.DeepCopy()
+	result, err := r.reconcileApplications(set)
+	set.Status.Message = ""
+	if err != nil {
+		reqLogger.Error(err, "Failed to reconcile the applications of AppsodyApplicationSet")
+		set.Status.Message = err.Error()
+	}
+
+	if !reflect.DeepEqual(status, &set.Status) {
+		if uerr := r.GetClient().Status().Update(context.TODO(), set); uerr != nil {
+			reqLogger.Error(uerr, "Unable to update status")
+			return reconcile.Result{Requeue: true}, nil
+		}
+	}
+	// Invalid sets are retried once they're changed
+	if errors.IsBadRequest(err) {
+		return reconcile.Result{}, nil
+	}
+	return result, err
+}
+
+func (r *ReconcileAppsodyApplicationSet) reconcileApplications(set *appsodyv1alpha1.AppsodyApplicationSet) (reconcile.Result, error) {
+	err := appsodyutils.ValidateApplicationSet(set)
+	if err != nil {
+		return reconcile.Result{}, errors.NewBadRequest(err.Error())
+	}
+	parameters, err := r.getParameters(set)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	rendered, err := appsodyutils.RenderApplicationSet(set, parameters)
+	if err != nil {
+		return reconcile.Result{}, errors.NewBadRequest(err.Error())
+	}
+	if err = r.checkNamespaces(set, rendered); err != nil {
+		return reconcile.Result{}, err
+	}
+	apps, err := r.getApplications(set)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	maxUnavailable := len(rendered)
+	if set.Spec.Rollout != nil && set.Spec.Rollout.MaxUnavailable != nil {
+		maxUnavailable = int(*set.Spec.Rollout.MaxUnavailable)
+	}
+	unavailable := 0
+	for _, desired := range rendered {
+		if app, ok := apps[types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}]; ok && !appsodyutils.IsApplicationReady(app) {
+			unavailable++
+		}
+	}
+
+	// Applications are created and updated in the order of the generators, as long as few enough of them are not ready
+	children := []appsodyv1alpha1.ApplicationSetChildStatus{}
+	requeue := false
+	for _, desired := range rendered {
+		name := types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}
+		app, ok := apps[name]
+		delete(apps, name)
+		child := appsodyv1alpha1.ApplicationSetChildStatus{Name: desired.Name, Namespace: desired.Namespace}
+
+		if !ok {
+			if unavailable >= maxUnavailable {
+				children = append(children, child)
+				continue
+			}
+			app = &appsodyv1alpha1.AppsodyApplication{ObjectMeta: metav1.ObjectMeta{Name: desired.Name, Namespace: desired.Namespace}}
+			if _, err = appsodyutils.MergeApplication(app, desired); err != nil {
+				return reconcile.Result{}, err
+			}
+			err = r.GetClient().Create(context.TODO(), app)
+			if errors.IsAlreadyExists(err) {
+				// Applications created since they were listed are updated on the next reconcile
+				if err = r.checkApplicationSetChild(set, name); err != nil {
+					return reconcile.Result{}, err
+				}
+				children = append(children, child)
+				requeue = true
+				continue
+			} else if err != nil {
+				return reconcile.Result{}, err
+			}
+			r.GetRecorder().Event(set, "Normal", "ApplicationCreated", fmt.Sprintf("Application %s is created", name))
+			unavailable++
+		} else {
+			updated := app.DeepCopy()
+			changed, err := appsodyutils.MergeApplication(updated, desired)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if changed {
+				// Updating applications which are not ready doesn't make more applications unavailable
+				ready := appsodyutils.IsApplicationReady(app)
+				if ready && unavailable >= maxUnavailable {
+					child.Ready = true
+					children = append(children, child)
+					continue
+				}
+				if err = r.GetClient().Update(context.TODO(), updated); err != nil {
+					return reconcile.Result{}, err
+				}
+				app = updated
+				if ready {
+					unavailable++
+				}
+			}
+		}
+		child.Ready = appsodyutils.IsApplicationReady(app)
+		child.Updated = true
+		children = append(children, child)
+	}
+
+	// The applications left are no longer generated
+	err = r.deleteApplications(set, apps)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	set.Status.Children = children
+	set.Status.Applications = int32(len(children))
+	set.Status.ReadyApplications, set.Status.UpdatedApplications = 0, 0
+	for _, child := range children {
+		if child.Ready {
+			set.Status.ReadyApplications++
+		}
+		if child.Updated {
+			set.Status.UpdatedApplications++
+		}
+	}
+
+	for _, g := range set.Spec.Generators {
+		if g.Namespaces != nil {
+			return reconcile.Result{Requeue: requeue, RequeueAfter: namespacesResyncInterval}, nil
+		}
+	}
+	for _, child := range children {
+		if child.Namespace != set.Namespace {
+			return reconcile.Result{Requeue: requeue, RequeueAfter: namespacesResyncInterval}, nil
+		}
+	}
+	return reconcile.Result{Requeue: requeue}, nil
+}
+
+// checkNamespaces returns an error unless the namespaces of the rendered applications allow the set to manage their
+// applications. Namespaces are not watched, so refused sets are retried until they're allowed.
+func (r *ReconcileAppsodyApplicationSet) checkNamespaces(set *appsodyv1alpha1.AppsodyApplicationSet, rendered []*appsodyv1alpha1.AppsodyApplication) error {
+	reader, err := r.GetAPIReader()
+	if err != nil {
+		return err
+	}
+	allowed := map[string]bool{set.Namespace: true}
+	for _, app := range rendered {
+		if allowed[app.Namespace] {
+			continue
+		}
+		ns := &corev1.Namespace{}
+		err = reader.Get(context.TODO(), types.NamespacedName{Name: app.Namespace}, ns)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err != nil || !appsodyutils.IsNamespaceAllowed(ns, appsodyutils.ApplicationSetsAllowedAnnotation, set.Namespace) {
+			return fmt.Errorf("Namespace %s doesn't allow application sets from namespace %s, add it to its %s annotation",
+				app.Namespace, set.Namespace, appsodyutils.ApplicationSetsAllowedAnnotation)
+		}
+		allowed[app.Namespace] = true
+	}
+	return nil
+}
+
+// checkApplicationSetChild returns an error unless the existing application is generated by the set
+func (r *ReconcileAppsodyApplicationSet) checkApplicationSetChild(set *appsodyv1alpha1.AppsodyApplicationSet, name types.NamespacedName) error {
+	reader, err := r.GetAPIReader()
+	if err != nil {
+		return err
+	}
+	app := &appsodyv1alpha1.AppsodyApplication{}
+	if err = reader.Get(context.TODO(), name, app); err != nil {
+		return err
+	}
+	if !appsodyutils.IsApplicationSetChild(app, set) {
+		return fmt.Errorf("Application %s already exists and is not generated by the set", name)
+	}
+	return nil
+}
+
+// getParameters returns the parameters of the applications of every generator of the set
+func (r *ReconcileAppsodyApplicationSet) getParameters(set *appsodyv1alpha1.AppsodyApplicationSet) ([]map[string]string, error) {
+	parameters := []map[string]string{}
+	for _, g := range set.Spec.Generators {
+		switch {
+		case g.List != nil:
+			for _, element := range g.List {
+				parameters = append(parameters, element.Parameters)
+			}
+		case g.Namespaces != nil:
+			selector, err := metav1.LabelSelectorAsSelector(g.Namespaces)
+			if err != nil {
+				return nil, errors.NewBadRequest(err.Error())
+			}
+			reader, err := r.GetAPIReader()
+			if err != nil {
+				return nil, err
+			}
+			namespaces := &corev1.NamespaceList{}
+			err = reader.List(context.TODO(), &client.ListOptions{LabelSelector: selector}, namespaces)
+			if err != nil {
+				return nil, err
+			}
+			sort.Slice(namespaces.Items, func(a, b int) bool {
+				return namespaces.Items[a].Name < namespaces.Items[b].Name
+			})
+			for _, ns := range namespaces.Items {
+				if selector.Matches(labels.Set(ns.Labels)) {
+					parameters = append(parameters, map[string]string{"namespace": ns.Name})
+				}
+			}
+		case g.ConfigMap != nil:
+			configMap := &corev1.ConfigMap{}
+			err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: g.ConfigMap.Name, Namespace: set.Namespace}, configMap)
+			if err != nil {
+				return nil, err
+			}
+			rows, err := appsodyutils.GetConfigMapParameters(configMap)
+			if err != nil {
+				return nil, errors.NewBadRequest(err.Error())
+			}
+			parameters = append(parameters, rows...)
+		}
+	}
+	return parameters, nil
+}
+
+// getApplications returns the applications generated by the set, in any namespace. They are listed from the API
+// server, as the operator may not watch the namespaces they're generated in.
+func (r *ReconcileAppsodyApplicationSet) getApplications(set *appsodyv1alpha1.AppsodyApplicationSet) (map[types.NamespacedName]*appsodyv1alpha1.AppsodyApplication, error) {
+	reader, err := r.GetAPIReader()
+	if err != nil {
+		return nil, err
+	}
+	list := &appsodyv1alpha1.AppsodyApplicationList{}
+	opts := (&client.ListOptions{}).MatchingLabels(map[string]string{
+		appsodyutils.ApplicationSetLabel:          set.Name,
+		appsodyutils.ApplicationSetNamespaceLabel: set.Namespace,
+	})
+	err = reader.List(context.TODO(), opts, list)
+	if err != nil {
+		return nil, err
+	}
+	apps := map[types.NamespacedName]*appsodyv1alpha1.AppsodyApplication{}
+	for i := range list.Items {
+		if appsodyutils.IsApplicationSetChild(&list.Items[i], set) {
+			apps[types.NamespacedName{Name: list.Items[i].Name, Namespace: list.Items[i].Namespace}] = &list.Items[i]
+		}
+	}
+	return apps, nil
+}
+
+// deleteApplications deletes the given applications of the set, or all of them if apps is nil
+func (r *ReconcileAppsodyApplicationSet) deleteApplications(set *appsodyv1alpha1.AppsodyApplicationSet, apps map[types.NamespacedName]*appsodyv1alpha1.AppsodyApplication) error {
+	if apps == nil {
+		var err error
+		if apps, err = r.getApplications(set); err != nil {
+			return err
+		}
+	}
+	for name, app := range apps {
+		err := r.DeleteResource(app)
+		if err != nil {
+			return err
+		}
+		r.GetRecorder().Event(set, "Normal", "ApplicationDeleted", fmt.Sprintf("Application %s is deleted", name))
+	}
+	return nil
+}
+
+func hasFinalizer(set *appsodyv1alpha1.AppsodyApplicationSet) bool {
+	for _, f := range set.Finalizers {
+		if f == appsodyutils.ApplicationSetFinalizer {
+			return true
+		}
+	}
+	return false
+}
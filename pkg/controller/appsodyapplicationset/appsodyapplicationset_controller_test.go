@@ -0,0 +1,412 @@
+package appsodyapplicationset
+
+import (
+	"context"
+	"testing"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	appsodyutils "github.com/appsody-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var (
+	name      = "orders"
+	namespace = "appsody"
+	stack     = "java-microprofile"
+)
+
+type Test struct {
+	test     string
+	expected interface{}
+	actual   interface{}
+}
+
+func TestApplicationSet(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	maxUnavailable := int32(1)
+	set := &appsodyv1alpha1.AppsodyApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "orders-uid"},
+		Spec: appsodyv1alpha1.AppsodyApplicationSetSpec{
+			Template: appsodyv1alpha1.AppsodyApplicationSetTemplate{
+				Metadata: appsodyv1alpha1.AppsodyApplicationSetTemplateMeta{
+					Name:   "orders-{{tenant}}",
+					Labels: map[string]string{"tenant": "{{tenant}}"},
+				},
+				Spec: appsodyv1alpha1.AppsodyApplicationSpec{
+					Stack:            stack,
+					ApplicationImage: "registry.example.com/orders:1.0",
+					Env:              []corev1.EnvVar{{Name: "TENANT", Value: "{{ tenant }}"}},
+				},
+			},
+			Generators: []appsodyv1alpha1.AppsodyApplicationSetGenerator{{
+				List: []appsodyv1alpha1.ApplicationSetListElement{
+					{Parameters: map[string]string{"tenant": "acme"}},
+					{Parameters: map[string]string{"tenant": "globex"}},
+					{Parameters: map[string]string{"tenant": "initech"}},
+				},
+			}},
+			Rollout: &appsodyv1alpha1.AppsodyApplicationSetRollout{MaxUnavailable: &maxUnavailable},
+		},
+	}
+
+	objs, s := []runtime.Object{set}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, set, &appsodyv1alpha1.AppsodyApplicationSetList{},
+		&appsodyv1alpha1.AppsodyApplication{}, &appsodyv1alpha1.AppsodyApplicationList{})
+	cl := fakeclient.NewFakeClient(objs...)
+
+	rb := appsodyutils.NewReconcilerBase(cl, s, &rest.Config{}, record.NewFakeRecorder(20))
+	r := &ReconcileAppsodyApplicationSet{rb}
+	r.SetAPIReader(cl)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	reconcileSet := func() reconcile.Result {
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*set = appsodyv1alpha1.AppsodyApplicationSet{}
+		if err = cl.Get(context.TODO(), req.NamespacedName, set); err != nil {
+			t.Fatalf("Get set: (%v)", err)
+		}
+		return result
+	}
+	getApp := func(n string) *appsodyv1alpha1.AppsodyApplication {
+		app := &appsodyv1alpha1.AppsodyApplication{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: n, Namespace: namespace}, app); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			t.Fatalf("Get application: (%v)", err)
+		}
+		return app
+	}
+	setReady := func(n string, ready corev1.ConditionStatus) {
+		app := getApp(n)
+		app.Status.Conditions = []appsodyv1alpha1.StatusCondition{{Type: appsodyv1alpha1.StatusConditionTypeReconciled, Status: ready}}
+		app.Status.ObservedGeneration = app.Generation
+		if err := cl.Status().Update(context.TODO(), app); err != nil {
+			t.Fatalf("Update application status: (%v)", err)
+		}
+	}
+	updateSet := func() {
+		if err := cl.Update(context.TODO(), set); err != nil {
+			t.Fatalf("Update set: (%v)", err)
+		}
+	}
+
+	// Applications are created one at a time, once the previous one is ready
+	reconcileSet()
+	acme := getApp("orders-acme")
+	createdTests := []Test{
+		{"finalizer", appsodyutils.ApplicationSetFinalizer, set.Finalizers[0]},
+		{"acme", false, acme == nil},
+		{"globex", true, getApp("orders-globex") == nil},
+		{"env", "acme", acme.Spec.Env[0].Value},
+		{"label", "acme", acme.Labels["tenant"]},
+		{"set label", name, acme.Labels[appsodyutils.ApplicationSetLabel]},
+		{"applications", int32(3), set.Status.Applications},
+		{"updated", int32(1), set.Status.UpdatedApplications},
+		{"ready", int32(0), set.Status.ReadyApplications},
+	}
+	verifyTests("created", createdTests, t)
+
+	reconcileSet()
+	verifyTests("held", []Test{{"globex", true, getApp("orders-globex") == nil}}, t)
+
+	setReady("orders-acme", corev1.ConditionTrue)
+	reconcileSet()
+	setReady("orders-globex", corev1.ConditionTrue)
+	reconcileSet()
+	setReady("orders-initech", corev1.ConditionTrue)
+	reconcileSet()
+	readyTests := []Test{
+		{"initech", false, getApp("orders-initech") == nil},
+		{"updated", int32(3), set.Status.UpdatedApplications},
+		{"ready", int32(3), set.Status.ReadyApplications},
+		{"child", "orders-globex", set.Status.Children[1].Name},
+	}
+	verifyTests("ready", readyTests, t)
+
+	// Fields the template doesn't set, such as the defaults of the stack, are kept
+	acme = getApp("orders-acme")
+	replicas := int32(2)
+	acme.Spec.Replicas = &replicas
+	if err := cl.Update(context.TODO(), acme); err != nil {
+		t.Fatalf("Update application: (%v)", err)
+	}
+	reconcileSet()
+	verifyTests("defaults", []Test{{"replicas", replicas, *getApp("orders-acme").Spec.Replicas}}, t)
+
+	// A new template is rolled out one application at a time
+	set.Spec.Template.Spec.ApplicationImage = "registry.example.com/orders:2.0"
+	set.Spec.Template.Spec.Env = nil
+	updateSet()
+	reconcileSet()
+	acme = getApp("orders-acme")
+	rolloutTests := []Test{
+		{"acme image", "registry.example.com/orders:2.0", acme.Spec.ApplicationImage},
+		{"acme env", 0, len(acme.Spec.Env)},
+		{"acme replicas", replicas, *acme.Spec.Replicas},
+		{"globex image", "registry.example.com/orders:1.0", getApp("orders-globex").Spec.ApplicationImage},
+		{"updated", int32(1), set.Status.UpdatedApplications},
+	}
+	verifyTests("rollout", rolloutTests, t)
+
+	setReady("orders-acme", corev1.ConditionFalse)
+	reconcileSet()
+	verifyTests("rollout held", []Test{{"globex image", "registry.example.com/orders:1.0", getApp("orders-globex").Spec.ApplicationImage}}, t)
+
+	setReady("orders-acme", corev1.ConditionTrue)
+	reconcileSet()
+	verifyTests("rollout resumed", []Test{{"globex image", "registry.example.com/orders:2.0", getApp("orders-globex").Spec.ApplicationImage}}, t)
+
+	// Applications which are no longer generated are deleted, unlike applications merely labeled with the set
+	labeled := &appsodyv1alpha1.AppsodyApplication{ObjectMeta: metav1.ObjectMeta{Name: "orders-umbrella", Namespace: namespace, Labels: acme.Labels}}
+	if err := cl.Create(context.TODO(), labeled); err != nil {
+		t.Fatalf("Create application: (%v)", err)
+	}
+	set.Spec.Generators[0].List = set.Spec.Generators[0].List[:2]
+	updateSet()
+	reconcileSet()
+	pruneTests := []Test{
+		{"initech", true, getApp("orders-initech") == nil},
+		{"labeled", false, getApp("orders-umbrella") == nil},
+		{"applications", int32(2), set.Status.Applications},
+	}
+	verifyTests("prune", pruneTests, t)
+
+	// Children are mapped to their set
+	requests := requestsForApplication(handler.MapObject{Meta: acme, Object: acme})
+	verifyTests("map", []Test{{"request", req, requests[0]}}, t)
+
+	// Templates with parameters the generators don't set are reported
+	set.Spec.Template.Metadata.Name = "orders-{{customer}}"
+	updateSet()
+	reconcileSet()
+	verifyTests("missing parameter", []Test{
+		{"message", "Parameters customer of the template are not set by the generator", set.Status.Message},
+		{"acme", false, getApp("orders-acme") == nil},
+	}, t)
+
+	// Deleting the set deletes its applications
+	now := metav1.Now()
+	set.DeletionTimestamp = &now
+	updateSet()
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	*set = appsodyv1alpha1.AppsodyApplicationSet{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, set); err != nil {
+		t.Fatalf("Get set: (%v)", err)
+	}
+	deleteTests := []Test{
+		{"acme", true, getApp("orders-acme") == nil},
+		{"globex", true, getApp("orders-globex") == nil},
+		{"labeled", false, getApp("orders-umbrella") == nil},
+		{"finalizers", 0, len(set.Finalizers)},
+	}
+	verifyTests("delete", deleteTests, t)
+}
+
+func TestApplicationSetGenerators(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	set := &appsodyv1alpha1.AppsodyApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "orders-uid"},
+		Spec: appsodyv1alpha1.AppsodyApplicationSetSpec{
+			Template: appsodyv1alpha1.AppsodyApplicationSetTemplate{
+				Metadata: appsodyv1alpha1.AppsodyApplicationSetTemplateMeta{Name: "orders-{{key}}"},
+				Spec:     appsodyv1alpha1.AppsodyApplicationSpec{Stack: stack, ApplicationImage: "registry.example.com/orders:{{version}}"},
+			},
+			Generators: []appsodyv1alpha1.AppsodyApplicationSetGenerator{{
+				ConfigMap: &appsodyv1alpha1.ApplicationSetConfigMapGenerator{Name: "tenants"},
+			}},
+		},
+	}
+	tenants := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants", Namespace: namespace},
+		Data:       map[string]string{"globex": `{"version": "2.0"}`, "acme": `{"version": "1.0"}`},
+	}
+	allowed := map[string]string{appsodyutils.ApplicationSetsAllowedAnnotation: namespace}
+	prod := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}, Annotations: allowed}}
+	dev := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"env": "dev"}, Annotations: allowed}}
+	qa := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "qa", Labels: map[string]string{"env": "qa"}}}
+
+	objs, s := []runtime.Object{set, tenants, prod, dev, qa}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, set, &appsodyv1alpha1.AppsodyApplicationSetList{},
+		&appsodyv1alpha1.AppsodyApplication{}, &appsodyv1alpha1.AppsodyApplicationList{})
+	cl := fakeclient.NewFakeClient(objs...)
+
+	// The cache only holds the namespace of the set, applications of other namespaces are read from the API server
+	rb := appsodyutils.NewReconcilerBase(&namespacedClient{cl, namespace}, s, &rest.Config{}, record.NewFakeRecorder(20))
+	r := &ReconcileAppsodyApplicationSet{rb}
+	r.SetAPIReader(cl)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	reconcileSet := func() reconcile.Result {
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*set = appsodyv1alpha1.AppsodyApplicationSet{}
+		if err = cl.Get(context.TODO(), req.NamespacedName, set); err != nil {
+			t.Fatalf("Get set: (%v)", err)
+		}
+		return result
+	}
+	listApplications := func() []appsodyv1alpha1.AppsodyApplication {
+		apps := &appsodyv1alpha1.AppsodyApplicationList{}
+		if err := cl.List(context.TODO(), &client.ListOptions{}, apps); err != nil {
+			t.Fatalf("List applications: (%v)", err)
+		}
+		return apps.Items
+	}
+	image := func(n types.NamespacedName) string {
+		app := &appsodyv1alpha1.AppsodyApplication{}
+		if err := cl.Get(context.TODO(), n, app); err != nil {
+			return ""
+		}
+		return app.Spec.ApplicationImage
+	}
+
+	// Every row of the ConfigMap generates an application
+	reconcileSet()
+	configMapTests := []Test{
+		{"acme", "registry.example.com/orders:1.0", image(types.NamespacedName{Name: "orders-acme", Namespace: namespace})},
+		{"globex", "registry.example.com/orders:2.0", image(types.NamespacedName{Name: "orders-globex", Namespace: namespace})},
+		{"first child", "orders-acme", set.Status.Children[0].Name},
+	}
+	verifyTests("configMap", configMapTests, t)
+
+	requests := requestsForConfigMap(cl)(handler.MapObject{Meta: tenants, Object: tenants})
+	verifyTests("map", []Test{{"requests", 1, len(requests)}}, t)
+
+	// Every matching namespace gets an application, and namespaces are checked again periodically
+	set.Spec.Template.Metadata = appsodyv1alpha1.AppsodyApplicationSetTemplateMeta{Name: "orders", Namespace: "{{namespace}}"}
+	set.Spec.Template.Spec.ApplicationImage = "registry.example.com/orders:1.0"
+	set.Spec.Generators = []appsodyv1alpha1.AppsodyApplicationSetGenerator{{
+		Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+	}}
+	if err := cl.Update(context.TODO(), set); err != nil {
+		t.Fatalf("Update set: (%v)", err)
+	}
+	reconcileSet()
+	result := reconcileSet()
+	namespacesTests := []Test{
+		{"prod", "registry.example.com/orders:1.0", image(types.NamespacedName{Name: "orders", Namespace: "prod"})},
+		{"dev", "", image(types.NamespacedName{Name: "orders", Namespace: "dev"})},
+		{"applications", 1, len(listApplications())},
+		{"message", "", set.Status.Message},
+		{"requeue after", namespacesResyncInterval, result.RequeueAfter},
+	}
+	verifyTests("namespaces", namespacesTests, t)
+
+	// Applications of namespaces no longer matching are deleted
+	set.Spec.Generators[0].Namespaces.MatchLabels["env"] = "dev"
+	if err := cl.Update(context.TODO(), set); err != nil {
+		t.Fatalf("Update set: (%v)", err)
+	}
+	reconcileSet()
+	verifyTests("moved", []Test{
+		{"prod", "", image(types.NamespacedName{Name: "orders", Namespace: "prod"})},
+		{"dev", "registry.example.com/orders:1.0", image(types.NamespacedName{Name: "orders", Namespace: "dev"})},
+	}, t)
+
+	// Applications the set doesn't generate are not taken over
+	prodApp := &appsodyv1alpha1.AppsodyApplication{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "prod"}}
+	if err := cl.Create(context.TODO(), prodApp); err != nil {
+		t.Fatalf("Create application: (%v)", err)
+	}
+	set.Spec.Generators[0].Namespaces.MatchLabels["env"] = "prod"
+	if err := cl.Update(context.TODO(), set); err != nil {
+		t.Fatalf("Update set: (%v)", err)
+	}
+	_, err := r.Reconcile(req)
+	*set = appsodyv1alpha1.AppsodyApplicationSet{}
+	if gerr := cl.Get(context.TODO(), req.NamespacedName, set); gerr != nil {
+		t.Fatalf("Get set: (%v)", gerr)
+	}
+	verifyTests("conflict", []Test{
+		{"failed", true, err != nil},
+		{"message", "Application prod/orders already exists and is not generated by the set", set.Status.Message},
+		{"prod", "", image(types.NamespacedName{Name: "orders", Namespace: "prod"})},
+	}, t)
+
+	// Namespaces that don't allow the set get no application
+	set.Spec.Generators[0].Namespaces.MatchLabels["env"] = "qa"
+	if err := cl.Update(context.TODO(), set); err != nil {
+		t.Fatalf("Update set: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	*set = appsodyv1alpha1.AppsodyApplicationSet{}
+	if gerr := cl.Get(context.TODO(), req.NamespacedName, set); gerr != nil {
+		t.Fatalf("Get set: (%v)", gerr)
+	}
+	verifyTests("refused", []Test{
+		{"failed", true, err != nil},
+		{"message", "Namespace qa doesn't allow application sets from namespace appsody, add it to its appsody.dev/allow-application-sets-from annotation", set.Status.Message},
+		{"qa", "", image(types.NamespacedName{Name: "orders", Namespace: "qa"})},
+	}, t)
+
+	// Generators must set exactly one source
+	set.Spec.Generators[0].List = []appsodyv1alpha1.ApplicationSetListElement{{Parameters: map[string]string{"namespace": "dev"}}}
+	if err := cl.Update(context.TODO(), set); err != nil {
+		t.Fatalf("Update set: (%v)", err)
+	}
+	reconcileSet()
+	verifyTests("invalid", []Test{{"message", "Generator 0 must set exactly one of list, namespaces or configMap", set.Status.Message}}, t)
+}
+
+// namespacedClient emulates the cache of an operator watching a single namespace: objects of other namespaces are
+// written to the API server but can't be read back
+type namespacedClient struct {
+	client.Client
+	namespace string
+}
+
+func (c *namespacedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if key.Namespace != c.namespace {
+		return errors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *namespacedClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	if err := c.Client.List(ctx, opts, list); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	cached := []runtime.Object{}
+	for _, item := range items {
+		if m, err := meta.Accessor(item); err == nil && m.GetNamespace() == c.namespace {
+			cached = append(cached, item)
+		}
+	}
+	return meta.SetList(list, cached)
+}
+
+func verifyTests(n string, tests []Test, t *testing.T) {
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("%s %s test expected: (%v) actual: (%v)", n, tt.test, tt.expected, tt.actual)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package appsodypromotion
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	appsodyutils "github.com/appsody-operator/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_appsodypromotion")
+
+// crossNamespaceResyncInterval is how often promotions are checked again when their source or target are in another
+// namespace, whose changes are not seen unless the operator watches it
+const crossNamespaceResyncInterval = time.Minute
+
+// Add creates a new AppsodyPromotion Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileAppsodyPromotion{ReconcilerBase: appsodyutils.NewReconcilerBase(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig(), mgr.GetRecorder("appsody-operator"))}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	c, err := controller.New("appsodypromotion-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	pred := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Approvals are given through annotations, which don't change metadata.Generation
+			return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+				!reflect.DeepEqual(e.MetaOld.GetAnnotations(), e.MetaNew.GetAnnotations())
+		},
+	}
+
+	err = c.Watch(&source.Kind{Type: &appsodyv1alpha1.AppsodyPromotion{}}, &handler.EnqueueRequestForObject{}, pred)
+	if err != nil {
+		return err
+	}
+
+	// Promote the image of the source once it's ready, and restore the target when it's changed
+	err = c.Watch(&source.Kind{Type: &appsodyv1alpha1.AppsodyApplication{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: requestsForApplication(mgr.GetClient()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// requestsForApplication maps an application to the promotions it's the source or the target of
+func requestsForApplication(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		promotions := &appsodyv1alpha1.AppsodyPromotionList{}
+		err := c.List(context.TODO(), &client.ListOptions{}, promotions)
+		if err != nil {
+			log.Error(err, "Failed to list AppsodyPromotions")
+			return nil
+		}
+
+		app := types.NamespacedName{Name: a.Meta.GetName(), Namespace: a.Meta.GetNamespace()}
+		requests := []reconcile.Request{}
+		for i := range promotions.Items {
+			p := &promotions.Items[i]
+			if appsodyutils.GetPromotionSource(p) == app || appsodyutils.GetPromotionTarget(p) == app {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: p.Name, Namespace: p.Namespace},
+				})
+			}
+		}
+		return requests
+	}
+}
+
+// blank assignment to verify that ReconcileAppsodyPromotion implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileAppsodyPromotion{}
+
+// ReconcileAppsodyPromotion reconciles a AppsodyPromotion object
+type ReconcileAppsodyPromotion struct {
+	appsodyutils.ReconcilerBase
+}
+
+// Reconcile copies the image and the fields of the source application of the promotion into its target, once the
+// source is ready and the image is approved
+func (r *ReconcileAppsodyPromotion) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling AppsodyPromotion")
+
+	promotion := &appsodyv1alpha1.AppsodyPromotion{}
+	err := r.GetClient().Get(context.TODO(), request.NamespacedName, promotion)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	status := promotion.Status.DeepCopy()
+	err = r.promote(promotion)
+	if err != nil {
+		reqLogger.Error(err, "Failed to promote application")
+		promotion.Status.Phase = appsodyv1alpha1.PromotionPhaseFailed
+		promotion.Status.Message = err.Error()
+	}
+
+	if !reflect.DeepEqual(status, &promotion.Status) {
+		if uerr := r.GetClient().Status().Update(context.TODO(), promotion); uerr != nil {
+			reqLogger.Error(uerr, "Unable to update status")
+			return reconcile.Result{Requeue: true}, nil
+		}
+	}
+	if err == nil && (appsodyutils.GetPromotionSource(promotion).Namespace != promotion.Namespace ||
+		appsodyutils.GetPromotionTarget(promotion).Namespace != promotion.Namespace) {
+		return reconcile.Result{RequeueAfter: crossNamespaceResyncInterval}, nil
+	}
+	return reconcile.Result{}, err
+}
+
+func (r *ReconcileAppsodyPromotion) promote(p *appsodyv1alpha1.AppsodyPromotion) error {
+	err := appsodyutils.ValidatePromotion(p)
+	if err != nil {
+		// Invalid promotions are retried once they're changed
+		setPhase(p, appsodyv1alpha1.PromotionPhaseFailed, err.Error())
+		return nil
+	}
+
+	// The source and the target can be in namespaces the operator doesn't watch, so they're read from the API server
+	reader, err := r.GetAPIReader()
+	if err != nil {
+		return err
+	}
+
+	// Promotions can't reach into namespaces that don't allow them, otherwise anyone able to create a promotion
+	// could read and write applications anywhere through the operator
+	sourceName, targetName := appsodyutils.GetPromotionSource(p), appsodyutils.GetPromotionTarget(p)
+	for _, namespace := range []string{sourceName.Namespace, targetName.Namespace} {
+		allowed, err := isNamespaceAllowed(reader, p, namespace)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			setPhase(p, appsodyv1alpha1.PromotionPhaseFailed, fmt.Sprintf("Namespace %s doesn't allow promotions from namespace %s, add it to its %s annotation",
+				namespace, p.Namespace, appsodyutils.PromotionsAllowedAnnotation))
+			return nil
+		}
+	}
+
+	source := &appsodyv1alpha1.AppsodyApplication{}
+	err = reader.Get(context.TODO(), sourceName, source)
+	if errors.IsNotFound(err) {
+		setPhase(p, appsodyv1alpha1.PromotionPhaseWaitingForSource, fmt.Sprintf("Application %s is not found", sourceName))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !appsodyutils.IsApplicationReady(source) {
+		setPhase(p, appsodyv1alpha1.PromotionPhaseWaitingForSource, fmt.Sprintf("Application %s is not reconciled", sourceName))
+		return nil
+	}
+
+	// Only the digest deployed for the source is promoted, never the tag it currently refers to
+	image := appsodyutils.GetImage(source)
+	if !strings.Contains(image, "@") {
+		setPhase(p, appsodyv1alpha1.PromotionPhaseWaitingForSource,
+			fmt.Sprintf("Image %s of application %s is not pinned by digest, set its imagePolicy", image, sourceName))
+		return nil
+	}
+	p.Status.Image = image
+
+	approval, approved := appsodyutils.GetPromotionApproval(p, image)
+	if !approved {
+		setPhase(p, appsodyv1alpha1.PromotionPhaseWaitingForApproval,
+			fmt.Sprintf("Set the %s annotation to the digest of image %s to promote it", appsodyutils.PromotionApprovalAnnotation, image))
+		return nil
+	}
+
+	target := &appsodyv1alpha1.AppsodyApplication{}
+	err = reader.Get(context.TODO(), targetName, target)
+	if errors.IsNotFound(err) {
+		// The target only gets the promoted fields, so it keeps the defaults and the profile of its own namespace
+		target = &appsodyv1alpha1.AppsodyApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: targetName.Name, Namespace: targetName.Namespace},
+			Spec:       appsodyv1alpha1.AppsodyApplicationSpec{Stack: source.Spec.Stack},
+		}
+		if _, err = appsodyutils.PromoteSpec(target, source, image, p.Spec.Fields); err != nil {
+			return err
+		}
+		err = r.GetClient().Create(context.TODO(), target)
+	} else if err == nil {
+		var changed bool
+		if changed, err = appsodyutils.PromoteSpec(target, source, image, p.Spec.Fields); err == nil && changed {
+			err = r.GetClient().Update(context.TODO(), target)
+		} else if err == nil {
+			setPhase(p, appsodyv1alpha1.PromotionPhasePromoted, fmt.Sprintf("Image %s is promoted to %s", image, targetName))
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	appsodyutils.AddPromotionRecord(p, image, approval)
+	setPhase(p, appsodyv1alpha1.PromotionPhasePromoted, fmt.Sprintf("Image %s is promoted to %s", image, targetName))
+	r.GetRecorder().Event(p, "Normal", "Promoted", fmt.Sprintf("Image %s is promoted from %s to %s", image, sourceName, targetName))
+	return nil
+}
+
+// isNamespaceAllowed returns whether the namespace allows the promotion to read and write its applications
+func isNamespaceAllowed(reader client.Reader, p *appsodyv1alpha1.AppsodyPromotion, namespace string) (bool, error) {
+	if namespace == p.Namespace {
+		return true, nil
+	}
+	ns := &corev1.Namespace{}
+	err := reader.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return appsodyutils.IsNamespaceAllowed(ns, appsodyutils.PromotionsAllowedAnnotation, p.Namespace), nil
+}
+
+func setPhase(p *appsodyv1alpha1.AppsodyPromotion, phase appsodyv1alpha1.PromotionPhase, message string) {
+	p.Status.Phase = phase
+	p.Status.Message = message
+}
@@ -0,0 +1,231 @@
+package appsodypromotion
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	appsodyutils "github.com/appsody-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+var (
+	name      = "promote-app"
+	namespace = "appsody"
+	stack     = "java-microprofile"
+	appImage  = "registry.example.com/my-app:latest"
+)
+
+type Test struct {
+	test     string
+	expected interface{}
+	actual   interface{}
+}
+
+func TestPromotion(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	pinned := "registry.example.com/my-app@" + digest
+	source := &appsodyv1alpha1.AppsodyApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "dev"},
+		Spec: appsodyv1alpha1.AppsodyApplicationSpec{
+			Stack:            stack,
+			ApplicationImage: appImage,
+			ImagePolicy:      &appsodyv1alpha1.AppsodyApplicationImagePolicy{},
+			Env:              []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+		},
+		Status: appsodyv1alpha1.AppsodyApplicationStatus{
+			ImageDigests: []appsodyv1alpha1.ImageDigestStatus{{Image: appImage, PinnedImage: pinned, Digest: digest}},
+		},
+	}
+	promotion := &appsodyv1alpha1.AppsodyPromotion{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appsodyv1alpha1.AppsodyPromotionSpec{
+			Source:          appsodyv1alpha1.PromotionReference{Name: "app", Namespace: "dev"},
+			Target:          appsodyv1alpha1.PromotionReference{Name: "app", Namespace: "prod"},
+			Fields:          []string{"env"},
+			RequireApproval: true,
+		},
+	}
+
+	// The source namespace allows the promotion, the target namespace doesn't yet
+	dev := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Annotations: map[string]string{appsodyutils.PromotionsAllowedAnnotation: "test, " + namespace}}}
+	prod := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}}
+
+	objs, s := []runtime.Object{source, promotion, dev, prod}, scheme.Scheme
+	s.AddKnownTypes(appsodyv1alpha1.SchemeGroupVersion, source, &appsodyv1alpha1.AppsodyApplicationList{}, promotion, &appsodyv1alpha1.AppsodyPromotionList{})
+	cl := fakeclient.NewFakeClient(objs...)
+
+	// The cache only holds the namespace of the promotion, the source and the target are read from the API server
+	rb := appsodyutils.NewReconcilerBase(&namespacedClient{cl, namespace}, s, &rest.Config{}, record.NewFakeRecorder(10))
+	r := &ReconcileAppsodyPromotion{rb}
+	r.SetAPIReader(cl)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	targetName := types.NamespacedName{Name: "app", Namespace: "prod"}
+	var result reconcile.Result
+	reconcilePromotion := func() {
+		var err error
+		if result, err = r.Reconcile(req); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		*promotion = appsodyv1alpha1.AppsodyPromotion{}
+		if err := cl.Get(context.TODO(), req.NamespacedName, promotion); err != nil {
+			t.Fatalf("Get promotion: (%v)", err)
+		}
+	}
+
+	// Namespaces that don't allow the promotion are neither read nor written
+	reconcilePromotion()
+	target := &appsodyv1alpha1.AppsodyApplication{}
+	unauthorizedTests := []Test{
+		{"phase", appsodyv1alpha1.PromotionPhaseFailed, promotion.Status.Phase},
+		{"message", true, strings.Contains(promotion.Status.Message, "Namespace prod doesn't allow promotions")},
+		{"image", "", promotion.Status.Image},
+		{"target", true, errors.IsNotFound(cl.Get(context.TODO(), targetName, target))},
+	}
+	verifyTests("unauthorized", unauthorizedTests, t)
+
+	prod.Annotations = map[string]string{appsodyutils.PromotionsAllowedAnnotation: "*"}
+	if err := cl.Update(context.TODO(), prod); err != nil {
+		t.Fatalf("Update namespace: (%v)", err)
+	}
+
+	// The promotion waits for the source to be reconciled
+	reconcilePromotion()
+	notReadyTests := []Test{
+		{"phase", appsodyv1alpha1.PromotionPhaseWaitingForSource, promotion.Status.Phase},
+		{"resync", crossNamespaceResyncInterval, result.RequeueAfter},
+	}
+	verifyTests("not ready", notReadyTests, t)
+
+	source.Status.Conditions = []appsodyv1alpha1.StatusCondition{{Type: appsodyv1alpha1.StatusConditionTypeReconciled, Status: corev1.ConditionTrue}}
+	if err := cl.Update(context.TODO(), source); err != nil {
+		t.Fatalf("Update source: (%v)", err)
+	}
+	reconcilePromotion()
+	waitingTests := []Test{
+		{"phase", appsodyv1alpha1.PromotionPhaseWaitingForApproval, promotion.Status.Phase},
+		{"image", pinned, promotion.Status.Image},
+		{"target", true, cl.Get(context.TODO(), targetName, target) != nil},
+	}
+	verifyTests("waiting for approval", waitingTests, t)
+
+	// Approving the digest creates the target with the promoted image and fields
+	promotion.Annotations = map[string]string{appsodyutils.PromotionApprovalAnnotation: digest}
+	if err := cl.Update(context.TODO(), promotion); err != nil {
+		t.Fatalf("Update promotion: (%v)", err)
+	}
+	reconcilePromotion()
+	if err := cl.Get(context.TODO(), targetName, target); err != nil {
+		t.Fatalf("Get target: (%v)", err)
+	}
+	promotedTests := []Test{
+		{"phase", appsodyv1alpha1.PromotionPhasePromoted, promotion.Status.Phase},
+		{"target image", pinned, target.Spec.ApplicationImage},
+		{"target stack", stack, target.Spec.Stack},
+		{"target env", "debug", target.Spec.Env[0].Value},
+		{"target image policy", true, target.Spec.ImagePolicy == nil},
+		{"history", 1, len(promotion.Status.History)},
+		{"history image", pinned, promotion.Status.History[0].Image},
+		{"history approval", digest, promotion.Status.History[0].Approval},
+	}
+	verifyTests("promoted", promotedTests, t)
+
+	// Promoting again doesn't change the target nor the history
+	reconcilePromotion()
+	verifyTests("repromoted", []Test{{"history", 1, len(promotion.Status.History)}}, t)
+
+	// A new digest of the source needs its own approval
+	newDigest := "sha256:" + strings.Repeat("b", 64)
+	newPinned := "registry.example.com/my-app@" + newDigest
+	source.Status.ImageDigests = append(source.Status.ImageDigests, appsodyv1alpha1.ImageDigestStatus{Image: appImage, PinnedImage: newPinned, Digest: newDigest})
+	if err := cl.Update(context.TODO(), source); err != nil {
+		t.Fatalf("Update source: (%v)", err)
+	}
+	reconcilePromotion()
+	target = &appsodyv1alpha1.AppsodyApplication{}
+	if err := cl.Get(context.TODO(), targetName, target); err != nil {
+		t.Fatalf("Get target: (%v)", err)
+	}
+	newDigestTests := []Test{
+		{"phase", appsodyv1alpha1.PromotionPhaseWaitingForApproval, promotion.Status.Phase},
+		{"image", newPinned, promotion.Status.Image},
+		{"target image", pinned, target.Spec.ApplicationImage},
+	}
+	verifyTests("new digest", newDigestTests, t)
+
+	// Changes to the target are reverted to the promoted image
+	promotion.Annotations[appsodyutils.PromotionApprovalAnnotation] = newPinned
+	if err := cl.Update(context.TODO(), promotion); err != nil {
+		t.Fatalf("Update promotion: (%v)", err)
+	}
+	target.Spec.ApplicationImage = appImage
+	if err := cl.Update(context.TODO(), target); err != nil {
+		t.Fatalf("Update target: (%v)", err)
+	}
+	reconcilePromotion()
+	target = &appsodyv1alpha1.AppsodyApplication{}
+	if err := cl.Get(context.TODO(), targetName, target); err != nil {
+		t.Fatalf("Get target: (%v)", err)
+	}
+	approvedTests := []Test{
+		{"phase", appsodyv1alpha1.PromotionPhasePromoted, promotion.Status.Phase},
+		{"target image", newPinned, target.Spec.ApplicationImage},
+		{"history", 2, len(promotion.Status.History)},
+	}
+	verifyTests("approved image", approvedTests, t)
+
+	// Changes to the source are mapped to the promotion
+	requests := requestsForApplication(cl)(handler.MapObject{Meta: source, Object: source})
+	mapTests := []Test{
+		{"requests", 1, len(requests)},
+		{"request", req, requests[0]},
+	}
+	verifyTests("map", mapTests, t)
+
+	// Invalid promotions fail without being retried
+	promotion.Spec.Fields = []string{"applicationImage"}
+	if err := cl.Update(context.TODO(), promotion); err != nil {
+		t.Fatalf("Update promotion: (%v)", err)
+	}
+	reconcilePromotion()
+	verifyTests("invalid", []Test{{"phase", appsodyv1alpha1.PromotionPhaseFailed, promotion.Status.Phase}}, t)
+}
+
+// namespacedClient emulates the cache of an operator watching a single namespace: objects of other namespaces are
+// written to the API server but can't be read back
+type namespacedClient struct {
+	client.Client
+	namespace string
+}
+
+func (c *namespacedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if key.Namespace != c.namespace {
+		return errors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func verifyTests(n string, tests []Test, t *testing.T) {
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("%s %s test expected: (%v) actual: (%v)", n, tt.test, tt.expected, tt.actual)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	imagev1 "github.com/openshift/api/image/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -19,6 +20,10 @@ func AddToManager(m manager.Manager) error {
 		return err
 	}
 
+	if err := imagev1.AddToScheme(m.GetScheme()); err != nil {
+		return err
+	}
+
 	for _, f := range AddToManagerFuncs {
 		if err := f(m); err != nil {
 			return err
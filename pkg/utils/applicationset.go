@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ApplicationSetLabel labels the applications of a set with its name
+const ApplicationSetLabel = "appsody.dev/application-set"
+
+// ApplicationSetNamespaceLabel labels the applications of a set with its namespace
+const ApplicationSetNamespaceLabel = "appsody.dev/application-set-namespace"
+
+// ApplicationSetUIDAnnotation records the UID of the set on its applications, so that applications merely labeled
+// with the set are not taken for its applications
+const ApplicationSetUIDAnnotation = "appsody.dev/application-set-uid"
+
+// ApplicationSetsAllowedAnnotation is set on namespaces to the namespaces whose sets can generate applications in them
+const ApplicationSetsAllowedAnnotation = "appsody.dev/allow-application-sets-from"
+
+// ApplicationSetFieldsAnnotation lists the fields of the spec of an application that are set by the template of its set
+const ApplicationSetFieldsAnnotation = "appsody.dev/application-set-fields"
+
+// ApplicationSetFinalizer deletes the applications of a set, which can't be owned by a set of another namespace
+const ApplicationSetFinalizer = "appsody.dev/application-set"
+
+var templateParameter = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// ValidateApplicationSet checks that the template is named and every generator sets exactly one source
+func ValidateApplicationSet(set *appsodyv1alpha1.AppsodyApplicationSet) error {
+	if set.Spec.Template.Metadata.Name == "" {
+		return fmt.Errorf("The name of the template is required")
+	}
+	for i, g := range set.Spec.Generators {
+		sources := 0
+		if g.List != nil {
+			sources++
+		}
+		if g.Namespaces != nil {
+			sources++
+		}
+		if g.ConfigMap != nil {
+			sources++
+		}
+		if sources != 1 {
+			return fmt.Errorf("Generator %d must set exactly one of list, namespaces or configMap", i)
+		}
+	}
+	return nil
+}
+
+// GetConfigMapParameters returns the parameters of the rows of the ConfigMap, in the order of their keys
+func GetConfigMapParameters(cm *corev1.ConfigMap) ([]map[string]string, error) {
+	keys := []string{}
+	for key := range cm.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := []map[string]string{}
+	for _, key := range keys {
+		row := map[string]string{}
+		if strings.TrimSpace(cm.Data[key]) != "" {
+			if err := json.Unmarshal([]byte(cm.Data[key]), &row); err != nil {
+				return nil, fmt.Errorf("Row %s of ConfigMap %s is not a JSON object of strings: %v", key, cm.Name, err)
+			}
+		}
+		row["key"] = key
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// RenderApplicationSet returns the applications of the set rendered from its template with each set of parameters
+func RenderApplicationSet(set *appsodyv1alpha1.AppsodyApplicationSet, parameters []map[string]string) ([]*appsodyv1alpha1.AppsodyApplication, error) {
+	b, err := json.Marshal(set.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	var template interface{}
+	if err = json.Unmarshal(b, &template); err != nil {
+		return nil, err
+	}
+
+	apps := []*appsodyv1alpha1.AppsodyApplication{}
+	names := map[types.NamespacedName]bool{}
+	for _, params := range parameters {
+		missing := map[string]bool{}
+		rendered, err := json.Marshal(renderTemplate(template, params, missing))
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) > 0 {
+			keys := []string{}
+			for key := range missing {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return nil, fmt.Errorf("Parameters %s of the template are not set by the generator", strings.Join(keys, ", "))
+		}
+		t := appsodyv1alpha1.AppsodyApplicationSetTemplate{}
+		if err = json.Unmarshal(rendered, &t); err != nil {
+			return nil, err
+		}
+
+		app := &appsodyv1alpha1.AppsodyApplication{Spec: t.Spec}
+		app.Name = t.Metadata.Name
+		app.Namespace = t.Metadata.Namespace
+		if app.Namespace == "" {
+			app.Namespace = set.Namespace
+		}
+		name := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+		if names[name] {
+			return nil, fmt.Errorf("Application %s is generated more than once", name)
+		}
+		names[name] = true
+
+		app.Labels = map[string]string{}
+		for k, v := range t.Metadata.Labels {
+			app.Labels[k] = v
+		}
+		app.Labels[ApplicationSetLabel] = set.Name
+		app.Labels[ApplicationSetNamespaceLabel] = set.Namespace
+		app.Annotations = map[string]string{}
+		for k, v := range t.Metadata.Annotations {
+			app.Annotations[k] = v
+		}
+		app.Annotations[ApplicationSetUIDAnnotation] = string(set.UID)
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// renderTemplate replaces the parameters of the strings of the decoded JSON value, recording the missing parameters
+func renderTemplate(value interface{}, params map[string]string, missing map[string]bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		return templateParameter.ReplaceAllStringFunc(v, func(match string) string {
+			key := templateParameter.FindStringSubmatch(match)[1]
+			if param, ok := params[key]; ok {
+				return param
+			}
+			missing[key] = true
+			return match
+		})
+	case map[string]interface{}:
+		rendered := map[string]interface{}{}
+		for key, item := range v {
+			rendered[key] = renderTemplate(item, params, missing)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderTemplate(item, params, missing)
+		}
+		return rendered
+	}
+	return value
+}
+
+// IsApplicationSetChild returns whether the application is generated by the set, as recorded by both its labels and
+// the UID of the set
+func IsApplicationSetChild(app *appsodyv1alpha1.AppsodyApplication, set *appsodyv1alpha1.AppsodyApplicationSet) bool {
+	return app.Labels[ApplicationSetLabel] == set.Name && app.Labels[ApplicationSetNamespaceLabel] == set.Namespace &&
+		app.Annotations[ApplicationSetUIDAnnotation] == string(set.UID)
+}
+
+// MergeApplication applies the labels, annotations and spec fields of the rendered application onto the application.
+// Fields of the spec the template no longer sets are removed, while the fields the template never set, such as the
+// defaults of the stack, are kept. Returns whether the application changed.
+func MergeApplication(app *appsodyv1alpha1.AppsodyApplication, rendered *appsodyv1alpha1.AppsodyApplication) (bool, error) {
+	before, err := json.Marshal([]interface{}{app.Labels, app.Annotations, app.Spec})
+	if err != nil {
+		return false, err
+	}
+
+	var current, desired map[string]interface{}
+	if b, err := json.Marshal(app.Spec); err != nil || json.Unmarshal(b, &current) != nil {
+		return false, fmt.Errorf("Failed to read the spec of application %s", app.Name)
+	}
+	if b, err := json.Marshal(rendered.Spec); err != nil || json.Unmarshal(b, &desired) != nil {
+		return false, fmt.Errorf("Failed to read the template of application %s", app.Name)
+	}
+	for _, field := range strings.Split(app.Annotations[ApplicationSetFieldsAnnotation], ",") {
+		if _, ok := desired[field]; !ok {
+			delete(current, field)
+		}
+	}
+	// Zero values set by the template, such as replicas: 0, are applied as well
+	b, err := json.Marshal(mergeObjects(current, desired, true))
+	if err != nil {
+		return false, err
+	}
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{}
+	if err = json.Unmarshal(b, &spec); err != nil {
+		return false, err
+	}
+	app.Spec = spec
+
+	if app.Labels == nil {
+		app.Labels = map[string]string{}
+	}
+	for k, v := range rendered.Labels {
+		app.Labels[k] = v
+	}
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+	for k, v := range rendered.Annotations {
+		app.Annotations[k] = v
+	}
+	fields := []string{}
+	for field := range desired {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	app.Annotations[ApplicationSetFieldsAnnotation] = strings.Join(fields, ",")
+
+	after, err := json.Marshal([]interface{}{app.Labels, app.Annotations, app.Spec})
+	if err != nil {
+		return false, err
+	}
+	return string(before) != string(after), nil
+}
@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// StackConstraint bounds the values a field of the applications of a stack can take
+type StackConstraint struct {
+	Field    string             `json:"field"`
+	Required bool               `json:"required,omitempty"`
+	Min      *resource.Quantity `json:"min,omitempty"`
+	Max      *resource.Quantity `json:"max,omitempty"`
+	// Upper bound of the limit of a resource relative to its request, for resourceConstraints.limits fields
+	MaxLimitRequestRatio *float64 `json:"maxLimitRequestRatio,omitempty"`
+	Allowed              []string `json:"allowed,omitempty"`
+	Pattern              string   `json:"pattern,omitempty"`
+	// Action taken when the bounds are not met, defaults to reject. Other checks always reject.
+	Action appsodyv1alpha1.ConstraintAction `json:"action,omitempty"`
+}
+
+const (
+	resourceRequestsField = "resourceConstraints.requests."
+	resourceLimitsField   = "resourceConstraints.limits."
+)
+
+type constraintFieldKind int
+
+const (
+	constraintFieldUnknown constraintFieldKind = iota
+	constraintFieldNumber
+	constraintFieldString
+	constraintFieldObject
+)
+
+func getConstraintFieldKind(field string) constraintFieldKind {
+	switch field {
+	case "replicas", "service.port", "autoscaling.minReplicas", "autoscaling.maxReplicas", "autoscaling.targetCPUUtilizationPercentage":
+		return constraintFieldNumber
+	case "applicationImage", "pullPolicy", "service.type", "serviceAccountName":
+		return constraintFieldString
+	case "readinessProbe", "livenessProbe", "autoscaling", "storage", "securityContext", "scheduling":
+		return constraintFieldObject
+	}
+	if strings.HasPrefix(field, resourceRequestsField) || strings.HasPrefix(field, resourceLimitsField) {
+		return constraintFieldNumber
+	}
+	return constraintFieldUnknown
+}
+
+// ValidateStackConstraint ...
+func ValidateStackConstraint(c StackConstraint) error {
+	kind := getConstraintFieldKind(c.Field)
+	switch {
+	case kind == constraintFieldUnknown:
+		return fmt.Errorf("Constraints are not supported for field `%v`", c.Field)
+	case kind != constraintFieldNumber && (c.Min != nil || c.Max != nil):
+		return fmt.Errorf("min and max are only supported for numeric fields, not `%v`", c.Field)
+	case kind == constraintFieldObject && (len(c.Allowed) > 0 || c.Pattern != ""):
+		return fmt.Errorf("allowed and pattern are not supported for field `%v`", c.Field)
+	case c.MaxLimitRequestRatio != nil && !strings.HasPrefix(c.Field, resourceLimitsField):
+		return fmt.Errorf("maxLimitRequestRatio is only supported for %s fields, not `%v`", resourceLimitsField, c.Field)
+	case c.MaxLimitRequestRatio != nil && *c.MaxLimitRequestRatio < 1:
+		return fmt.Errorf("maxLimitRequestRatio of field `%v` must be at least 1", c.Field)
+	case c.Action != "" && c.Action != appsodyv1alpha1.ConstraintActionReject && c.Action != appsodyv1alpha1.ConstraintActionClamp:
+		return fmt.Errorf("Unknown constraint action `%v`, must be %v or %v", c.Action, appsodyv1alpha1.ConstraintActionReject, appsodyv1alpha1.ConstraintActionClamp)
+	}
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("Invalid pattern for field `%v`: %v", c.Field, err)
+		}
+	}
+	return nil
+}
+
+// ValidateStackConstants ...
+func ValidateStackConstants(constants *StackConstants) error {
+	if err := ValidatePolicyMode(constants.PolicyMode); err != nil {
+		return err
+	}
+	for _, c := range constants.Constraints {
+		if err := ValidateStackConstraint(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyConstraints checks the application against the constraints of its stack and clamps the values of the
+// application where allowed when the policy is enforced. It returns the fields which don't meet the constraints
+// and an error listing those which reject the application.
+func ApplyConstraints(cr *appsodyv1alpha1.AppsodyApplication, constants *StackConstants) ([]appsodyv1alpha1.PolicyViolation, error) {
+	if constants == nil {
+		return nil, nil
+	}
+	mode := constants.GetPolicyMode()
+	var violations []appsodyv1alpha1.PolicyViolation
+	var rejected []string
+	for _, c := range constants.Constraints {
+		for _, v := range checkConstraint(cr, c, mode == appsodyv1alpha1.PolicyModeEnforce) {
+			v.Mode = mode
+			if mode != appsodyv1alpha1.PolicyModeEnforce {
+				v.Action = ""
+			} else if v.Action == appsodyv1alpha1.ConstraintActionReject {
+				rejected = append(rejected, fmt.Sprintf("%s must be %s", v.Field, v.Constant))
+			}
+			violations = append(violations, v)
+		}
+	}
+	if len(rejected) > 0 {
+		return violations, fmt.Errorf("The application does not meet the constraints of stack `%v`: %s", cr.Spec.Stack, strings.Join(rejected, ", "))
+	}
+	return violations, nil
+}
+
+func checkConstraint(cr *appsodyv1alpha1.AppsodyApplication, c StackConstraint, enforce bool) []appsodyv1alpha1.PolicyViolation {
+	action := c.Action
+	if action == "" {
+		action = appsodyv1alpha1.ConstraintActionReject
+	}
+	violation := func(value interface{}, constraint string, action appsodyv1alpha1.ConstraintAction) appsodyv1alpha1.PolicyViolation {
+		return appsodyv1alpha1.PolicyViolation{Field: c.Field, Value: formatPolicyValue(value), Constant: constraint, Action: action}
+	}
+
+	value, set := getConstraintField(cr, c.Field)
+	if !set {
+		if c.Required {
+			return []appsodyv1alpha1.PolicyViolation{violation(nil, "set", appsodyv1alpha1.ConstraintActionReject)}
+		}
+		return nil
+	}
+
+	str := fmt.Sprintf("%v", value)
+	if q, ok := value.(resource.Quantity); ok {
+		str = q.String()
+	}
+
+	var violations []appsodyv1alpha1.PolicyViolation
+	if len(c.Allowed) > 0 {
+		found := false
+		for _, a := range c.Allowed {
+			if a == str {
+				found = true
+			}
+		}
+		if !found {
+			violations = append(violations, violation(value, fmt.Sprintf("one of %s", strings.Join(c.Allowed, ", ")), appsodyv1alpha1.ConstraintActionReject))
+		}
+	}
+	if c.Pattern != "" && !regexp.MustCompile(c.Pattern).MatchString(str) {
+		violations = append(violations, violation(value, fmt.Sprintf("matching %s", c.Pattern), appsodyv1alpha1.ConstraintActionReject))
+	}
+
+	if q, ok := value.(resource.Quantity); ok {
+		if bound := getConstraintBound(q, c.Min, c.Max); bound != nil {
+			violations = append(violations, violation(value, describeConstraintBounds(c), action))
+			if enforce && action == appsodyv1alpha1.ConstraintActionClamp {
+				setConstraintField(cr, c.Field, *bound)
+			}
+		}
+		if bound := getLimitRequestBound(cr, c); bound != nil {
+			violations = append(violations, violation(value, describeLimitRequestRatio(c), action))
+			if enforce && action == appsodyv1alpha1.ConstraintActionClamp {
+				setConstraintField(cr, c.Field, *bound)
+			}
+		}
+	}
+	return violations
+}
+
+// getConstraintBound returns the bound to clamp the quantity to when it's out of bounds
+func getConstraintBound(q resource.Quantity, min, max *resource.Quantity) *resource.Quantity {
+	if min != nil && q.Cmp(*min) < 0 {
+		return min
+	}
+	if max != nil && q.Cmp(*max) > 0 {
+		return max
+	}
+	return nil
+}
+
+// getLimitRequestBound returns the highest limit allowed for the request when the limit exceeds it
+func getLimitRequestBound(cr *appsodyv1alpha1.AppsodyApplication, c StackConstraint) *resource.Quantity {
+	if c.MaxLimitRequestRatio == nil {
+		return nil
+	}
+	limit, ok := getConstraintField(cr, c.Field)
+	request, ok2 := getConstraintField(cr, resourceRequestsField+strings.TrimPrefix(c.Field, resourceLimitsField))
+	if !ok || !ok2 {
+		return nil
+	}
+	l, r := limit.(resource.Quantity), request.(resource.Quantity)
+	bound := resource.NewMilliQuantity(int64(float64(r.MilliValue())**c.MaxLimitRequestRatio), r.Format)
+	if l.Cmp(*bound) <= 0 {
+		return nil
+	}
+	return bound
+}
+
+func describeConstraintBounds(c StackConstraint) string {
+	switch {
+	case c.Min != nil && c.Max != nil:
+		return fmt.Sprintf("between %s and %s", c.Min.String(), c.Max.String())
+	case c.Min != nil:
+		return fmt.Sprintf("at least %s", c.Min.String())
+	default:
+		return fmt.Sprintf("at most %s", c.Max.String())
+	}
+}
+
+func describeLimitRequestRatio(c StackConstraint) string {
+	return fmt.Sprintf("at most %v times the request", *c.MaxLimitRequestRatio)
+}
+
+// getConstraintField returns the value of the field of the application, numbers as quantities, and whether it's set
+func getConstraintField(cr *appsodyv1alpha1.AppsodyApplication, field string) (interface{}, bool) {
+	spec := cr.Spec
+	number := func(v int32) resource.Quantity {
+		return *resource.NewQuantity(int64(v), resource.DecimalSI)
+	}
+	switch field {
+	case "replicas":
+		if spec.Replicas != nil {
+			return number(*spec.Replicas), true
+		}
+	case "service.port":
+		if spec.Service != nil && spec.Service.Port != 0 {
+			return number(spec.Service.Port), true
+		}
+	case "autoscaling.minReplicas":
+		if spec.Autoscaling != nil && spec.Autoscaling.MinReplicas != nil {
+			return number(*spec.Autoscaling.MinReplicas), true
+		}
+	case "autoscaling.maxReplicas":
+		if spec.Autoscaling != nil && spec.Autoscaling.MaxReplicas != 0 {
+			return number(spec.Autoscaling.MaxReplicas), true
+		}
+	case "autoscaling.targetCPUUtilizationPercentage":
+		if spec.Autoscaling != nil && spec.Autoscaling.TargetCPUUtilizationPercentage != nil {
+			return number(*spec.Autoscaling.TargetCPUUtilizationPercentage), true
+		}
+	case "applicationImage":
+		return spec.ApplicationImage, spec.ApplicationImage != ""
+	case "pullPolicy":
+		if spec.PullPolicy != nil {
+			return string(*spec.PullPolicy), true
+		}
+	case "service.type":
+		if spec.Service != nil && spec.Service.Type != nil {
+			return string(*spec.Service.Type), true
+		}
+	case "serviceAccountName":
+		if spec.ServiceAccountName != nil && *spec.ServiceAccountName != "" {
+			return *spec.ServiceAccountName, true
+		}
+	case "readinessProbe":
+		return spec.ReadinessProbe, spec.ReadinessProbe != nil
+	case "livenessProbe":
+		return spec.LivenessProbe, spec.LivenessProbe != nil
+	case "autoscaling":
+		return spec.Autoscaling, spec.Autoscaling != nil
+	case "storage":
+		return spec.Storage, spec.Storage != nil
+	case "securityContext":
+		return spec.SecurityContext, spec.SecurityContext != nil
+	case "scheduling":
+		return spec.Scheduling, spec.Scheduling != nil
+	default:
+		if spec.ResourceConstraints == nil {
+			return nil, false
+		}
+		list, name := spec.ResourceConstraints.Requests, strings.TrimPrefix(field, resourceRequestsField)
+		if strings.HasPrefix(field, resourceLimitsField) {
+			list, name = spec.ResourceConstraints.Limits, strings.TrimPrefix(field, resourceLimitsField)
+		}
+		if q, ok := list[corev1.ResourceName(name)]; ok {
+			return q, true
+		}
+	}
+	return nil, false
+}
+
+// setConstraintField sets a numeric field of the application which is already set. Structs are copied first
+// as they may be shared with the defaults and constants of the stack.
+func setConstraintField(cr *appsodyv1alpha1.AppsodyApplication, field string, q resource.Quantity) {
+	v := int32(q.Value())
+	switch field {
+	case "replicas":
+		cr.Spec.Replicas = &v
+	case "service.port":
+		cr.Spec.Service = cr.Spec.Service.DeepCopy()
+		cr.Spec.Service.Port = v
+	case "autoscaling.minReplicas":
+		cr.Spec.Autoscaling = cr.Spec.Autoscaling.DeepCopy()
+		cr.Spec.Autoscaling.MinReplicas = &v
+	case "autoscaling.maxReplicas":
+		cr.Spec.Autoscaling = cr.Spec.Autoscaling.DeepCopy()
+		cr.Spec.Autoscaling.MaxReplicas = v
+	case "autoscaling.targetCPUUtilizationPercentage":
+		cr.Spec.Autoscaling = cr.Spec.Autoscaling.DeepCopy()
+		cr.Spec.Autoscaling.TargetCPUUtilizationPercentage = &v
+	default:
+		cr.Spec.ResourceConstraints = cr.Spec.ResourceConstraints.DeepCopy()
+		if strings.HasPrefix(field, resourceLimitsField) {
+			cr.Spec.ResourceConstraints.Limits[corev1.ResourceName(strings.TrimPrefix(field, resourceLimitsField))] = q
+		} else {
+			cr.Spec.ResourceConstraints.Requests[corev1.ResourceName(strings.TrimPrefix(field, resourceRequestsField))] = q
+		}
+	}
+}
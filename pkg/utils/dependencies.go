@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// GetDependencies returns the applications the application depends on
+func GetDependencies(cr *appsodyv1alpha1.AppsodyApplication) []types.NamespacedName {
+	dependencies := []types.NamespacedName{}
+	for _, dep := range cr.Spec.DependsOn {
+		namespace := dep.Namespace
+		if namespace == "" {
+			namespace = cr.Namespace
+		}
+		dependencies = append(dependencies, types.NamespacedName{Name: dep.Name, Namespace: namespace})
+	}
+	return dependencies
+}
+
+// ValidateDependencies ...
+func ValidateDependencies(cr *appsodyv1alpha1.AppsodyApplication) error {
+	for _, dep := range cr.Spec.DependsOn {
+		if dep.Name == "" {
+			return fmt.Errorf("A name is required for every application of dependsOn")
+		}
+	}
+	return nil
+}
+
+// IsDependency returns whether the application depends on the given application
+func IsDependency(cr *appsodyv1alpha1.AppsodyApplication, app types.NamespacedName) bool {
+	for _, dep := range GetDependencies(cr) {
+		if dep == app {
+			return true
+		}
+	}
+	return false
+}
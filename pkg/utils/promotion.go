@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PromotionApprovalAnnotation approves the promotion of the image, or of the digest, it's set to
+const PromotionApprovalAnnotation = "appsody.dev/approved"
+
+// PromotionsAllowedAnnotation is set on namespaces to the namespaces whose promotions can read and write their
+// applications
+const PromotionsAllowedAnnotation = "appsody.dev/allow-promotions-from"
+
+// maxPromotionRecords bounds the history kept in the status of a promotion
+const maxPromotionRecords = 20
+
+// GetPromotionSource ...
+func GetPromotionSource(p *appsodyv1alpha1.AppsodyPromotion) types.NamespacedName {
+	return getPromotionReference(p, p.Spec.Source)
+}
+
+// GetPromotionTarget ...
+func GetPromotionTarget(p *appsodyv1alpha1.AppsodyPromotion) types.NamespacedName {
+	return getPromotionReference(p, p.Spec.Target)
+}
+
+func getPromotionReference(p *appsodyv1alpha1.AppsodyPromotion, ref appsodyv1alpha1.PromotionReference) types.NamespacedName {
+	if ref.Namespace == "" {
+		return types.NamespacedName{Name: ref.Name, Namespace: p.Namespace}
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+}
+
+// ValidatePromotion checks that the promotion names distinct applications and only copies fields of their spec
+func ValidatePromotion(p *appsodyv1alpha1.AppsodyPromotion) error {
+	if p.Spec.Source.Name == "" || p.Spec.Target.Name == "" {
+		return fmt.Errorf("Both the source and the target of the promotion must be set")
+	}
+	if GetPromotionSource(p) == GetPromotionTarget(p) {
+		return fmt.Errorf("The source and the target of the promotion must be different applications")
+	}
+	fields := getSpecFields()
+	for _, field := range p.Spec.Fields {
+		if !fields[field] || field == "applicationImage" {
+			return fmt.Errorf("Field %s can't be promoted", field)
+		}
+	}
+	return nil
+}
+
+// getSpecFields returns the JSON names of the fields of the application spec
+func getSpecFields() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(appsodyv1alpha1.AppsodyApplicationSpec{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// IsApplicationReady returns whether the current spec of the application is reconciled
+func IsApplicationReady(cr *appsodyv1alpha1.AppsodyApplication) bool {
+	c := GetCondition(appsodyv1alpha1.StatusConditionTypeReconciled, &cr.Status)
+	return c != nil && c.Status == corev1.ConditionTrue && cr.Status.ObservedGeneration == cr.Generation
+}
+
+// GetPromotionApproval returns the approval annotation of the promotion and whether it approves the image
+func GetPromotionApproval(p *appsodyv1alpha1.AppsodyPromotion, image string) (string, bool) {
+	approval := p.Annotations[PromotionApprovalAnnotation]
+	if !p.Spec.RequireApproval {
+		return approval, true
+	}
+	if approval == "" {
+		return "", false
+	}
+	return approval, approval == image || strings.HasSuffix(image, "@"+approval)
+}
+
+// PromoteSpec sets the image of the target and copies the fields of the promotion from the source. Returns
+// whether the target changed.
+func PromoteSpec(target *appsodyv1alpha1.AppsodyApplication, source *appsodyv1alpha1.AppsodyApplication, image string, fields []string) (bool, error) {
+	var sourceSpec, targetSpec map[string]interface{}
+	if b, err := json.Marshal(source.Spec); err != nil || json.Unmarshal(b, &sourceSpec) != nil {
+		return false, fmt.Errorf("Failed to read the spec of application %s", source.Name)
+	}
+	if b, err := json.Marshal(target.Spec); err != nil || json.Unmarshal(b, &targetSpec) != nil {
+		return false, fmt.Errorf("Failed to read the spec of application %s", target.Name)
+	}
+	for _, field := range fields {
+		if value, ok := sourceSpec[field]; ok {
+			targetSpec[field] = value
+		} else {
+			delete(targetSpec, field)
+		}
+	}
+	targetSpec["applicationImage"] = image
+
+	// Specs are compared in their JSON form, as quantities don't keep their format when decoded
+	current, err := json.Marshal(target.Spec)
+	if err != nil {
+		return false, err
+	}
+	promoted, err := json.Marshal(targetSpec)
+	if err != nil {
+		return false, err
+	}
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{}
+	if err = json.Unmarshal(promoted, &spec); err != nil {
+		return false, err
+	}
+	if promoted, err = json.Marshal(spec); err != nil || string(promoted) == string(current) {
+		return false, err
+	}
+	target.Spec = spec
+	return true, nil
+}
+
+// AddPromotionRecord ...
+func AddPromotionRecord(p *appsodyv1alpha1.AppsodyPromotion, image string, approval string) {
+	p.Status.History = append(p.Status.History, appsodyv1alpha1.PromotionRecord{
+		Image:      image,
+		Fields:     p.Spec.Fields,
+		Approval:   approval,
+		PromotedAt: metav1.Now(),
+	})
+	if len(p.Status.History) > maxPromotionRecords {
+		p.Status.History = p.Status.History[len(p.Status.History)-maxPromotionRecords:]
+	}
+}
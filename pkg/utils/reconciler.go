@@ -2,12 +2,16 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
 	"time"
 
 	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,6 +34,8 @@ type ReconcilerBase struct {
 	recorder   record.EventRecorder
 	restConfig *rest.Config
 	discovery  discovery.DiscoveryInterface
+	apiReader  client.Reader
+	inspector  *ImageInspector
 }
 
 //NewReconcilerBase creates a new ReconcilerBase
@@ -47,6 +53,11 @@ func (r *ReconcilerBase) GetClient() client.Client {
 	return r.client
 }
 
+// GetScheme returns the scheme
+func (r *ReconcilerBase) GetScheme() *runtime.Scheme {
+	return r.scheme
+}
+
 // GetRecorder returns the underlying recorder
 func (r *ReconcilerBase) GetRecorder() record.EventRecorder {
 	return r.recorder
@@ -68,6 +79,33 @@ func (r *ReconcilerBase) SetDiscoveryClient(discovery discovery.DiscoveryInterfa
 	r.discovery = discovery
 }
 
+// GetImageInspector returns the inspector of application images, or nil when images are not inspected
+func (r *ReconcilerBase) GetImageInspector() *ImageInspector {
+	return r.inspector
+}
+
+// SetImageInspector ...
+func (r *ReconcilerBase) SetImageInspector(inspector *ImageInspector) {
+	r.inspector = inspector
+}
+
+// GetAPIReader returns a client that reads directly from the API server. Used for cluster scoped resources
+// that are not available through the namespaced cache of the manager.
+func (r *ReconcilerBase) GetAPIReader() (client.Reader, error) {
+	if r.apiReader == nil {
+		var err error
+		r.apiReader, err = client.New(r.restConfig, client.Options{Scheme: r.scheme})
+		return r.apiReader, err
+	}
+
+	return r.apiReader, nil
+}
+
+// SetAPIReader ...
+func (r *ReconcilerBase) SetAPIReader(reader client.Reader) {
+	r.apiReader = reader
+}
+
 var log = logf.Log.WithName("utils")
 
 // CreateOrUpdate ...
@@ -130,6 +168,21 @@ func (r *ReconcilerBase) GetAppsodyOpConfigMap(name string, ns string) (*corev1.
 	return configMap, nil
 }
 
+// GetPullSecretObjects returns the image pull secrets of the application which exist
+func (r *ReconcilerBase) GetPullSecretObjects(cr *appsodyv1alpha1.AppsodyApplication) ([]corev1.Secret, error) {
+	var secrets []corev1.Secret
+	for _, ref := range GetPullSecrets(cr) {
+		secret := corev1.Secret{}
+		err := r.GetClient().Get(context.TODO(), types.NamespacedName{Name: ref.Name, Namespace: cr.Namespace}, &secret)
+		if err == nil {
+			secrets = append(secrets, secret)
+		} else if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
+
 // ManageError ...
 func (r *ReconcilerBase) ManageError(issue error, conditionType appsodyv1alpha1.StatusConditionType, cr *appsodyv1alpha1.AppsodyApplication) (reconcile.Result, error) {
 	r.GetRecorder().Event(cr, "Warning", "ProcessingError", issue.Error())
@@ -159,6 +212,9 @@ func (r *ReconcilerBase) ManageError(issue error, conditionType appsodyv1alpha1.
 	}
 
 	SetCondition(newCondition, &cr.Status)
+	if conditionType == appsodyv1alpha1.StatusConditionTypeReconciled {
+		cr.Status.ObservedGeneration = cr.Generation
+	}
 
 	err := r.GetClient().Status().Update(context.Background(), cr)
 	if err != nil {
@@ -212,6 +268,9 @@ func (r *ReconcilerBase) ManageSuccess(conditionType appsodyv1alpha1.StatusCondi
 	}
 
 	SetCondition(statusCondition, &cr.Status)
+	if conditionType == appsodyv1alpha1.StatusConditionTypeReconciled {
+		cr.Status.ObservedGeneration = cr.Generation
+	}
 	err := r.GetClient().Status().Update(context.Background(), cr)
 	if err != nil {
 		log.Error(err, "Unable to update status")
@@ -223,6 +282,69 @@ func (r *ReconcilerBase) ManageSuccess(conditionType appsodyv1alpha1.StatusCondi
 	return reconcile.Result{}, nil
 }
 
+// IsVolumeExpansionAllowed checks whether the storage class of the PVC allows it to be expanded. PVCs that
+// don't name a storage class use the default class of the cluster.
+func (r *ReconcilerBase) IsVolumeExpansionAllowed(pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	reader, err := r.GetAPIReader()
+	if err != nil {
+		return false, err
+	}
+
+	if pvc.Spec.StorageClassName != nil {
+		if *pvc.Spec.StorageClassName == "" {
+			return false, nil
+		}
+		storageClass := &storagev1.StorageClass{}
+		err = reader.Get(context.TODO(), types.NamespacedName{Name: *pvc.Spec.StorageClassName}, storageClass)
+		if err != nil {
+			return false, err
+		}
+		return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+	}
+
+	storageClasses := &storagev1.StorageClassList{}
+	err = reader.List(context.TODO(), &client.ListOptions{}, storageClasses)
+	if err != nil {
+		return false, err
+	}
+	for _, storageClass := range storageClasses.Items {
+		if storageClass.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" ||
+			storageClass.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+			return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+		}
+	}
+	return false, nil
+}
+
+// GetKnativeServingVersion returns the newest Knative Serving group version served by the cluster, or an
+// empty string if Knative Serving is not installed
+func (r *ReconcilerBase) GetKnativeServingVersion() (string, error) {
+	cli, err := r.GetDiscoveryClient()
+	if err != nil {
+		log.Error(err, "Failed to return a discovery client for the current reconciler")
+		return "", err
+	}
+
+	groups, err := cli.ServerGroups()
+	if err != nil {
+		return "", err
+	}
+
+	served := map[string]bool{}
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			served[version.GroupVersion] = true
+		}
+	}
+
+	for _, gv := range KnativeServingVersions {
+		if served[gv] {
+			return gv, nil
+		}
+	}
+	return "", nil
+}
+
 // IsGroupVersionSupported ...
 func (r *ReconcilerBase) IsGroupVersionSupported(groupVersion string) (bool, error) {
 	cli, err := r.GetDiscoveryClient()
@@ -242,3 +364,40 @@ func (r *ReconcilerBase) IsGroupVersionSupported(groupVersion string) (bool, err
 
 	return true, nil
 }
+
+// GetConfigHash returns a hash of the contents of the ConfigMaps and Secrets referenced by the application,
+// or an empty string if it references none. Missing references are hashed as empty so creating them changes the hash.
+func (r *ReconcilerBase) GetConfigHash(cr *appsodyv1alpha1.AppsodyApplication) (string, error) {
+	references := GetConfigReferences(cr)
+	if len(references) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, ref := range references {
+		key := types.NamespacedName{Name: ref.Name, Namespace: cr.Namespace}
+		var data interface{}
+		var err error
+		if ref.Kind == appsodyv1alpha1.ConfigReferenceKindSecret {
+			secret := &corev1.Secret{}
+			if err = r.client.Get(context.TODO(), key, secret); err == nil {
+				data = secret.Data
+			}
+		} else {
+			configMap := &corev1.ConfigMap{}
+			if err = r.client.Get(context.TODO(), key, configMap); err == nil {
+				data = []interface{}{configMap.Data, configMap.BinaryData}
+			}
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+
+		content, err := json.Marshal([]interface{}{ref, data})
+		if err != nil {
+			return "", err
+		}
+		hash.Write(content)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
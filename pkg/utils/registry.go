@@ -0,0 +1,404 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	imagename "github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// StackIDLabel is set by the Appsody CLI on application images to the stack they were built from
+const StackIDLabel = "dev.appsody.stack.id"
+
+// StackVersionLabel is set by the Appsody CLI on application images to the version of their stack
+const StackVersionLabel = "dev.appsody.stack.version"
+
+// maxCachedImages bounds the number of images whose metadata is kept in memory
+const maxCachedImages = 256
+
+// ImageMetadata holds what the operator reads from the config of an application image
+type ImageMetadata struct {
+	Digest       string
+	StackID      string
+	StackVersion string
+	// TCP ports exposed by the image, in ascending order
+	Ports  []int32
+	Labels map[string]string
+	// Architectures of the linux images of the index, or the architecture of the image
+	Architectures []string
+}
+
+// registryTimeout bounds every request to a registry, including reading its response
+const registryTimeout = 10 * time.Second
+
+// minInspectBackoff and maxInspectBackoff bound the time an image that failed to be inspected isn't inspected again
+const (
+	minInspectBackoff = 30 * time.Second
+	maxInspectBackoff = 10 * time.Minute
+)
+
+// tagDigestTTL is how long the digest a tag refers to is reused before the registry is asked again
+const tagDigestTTL = 30 * time.Second
+
+// ImageInspector reads the metadata of images from their registry, caching it by digest. The digests of tags are
+// cached for a short time, so that reconciles don't look up the same tag again. Failures are cached with an
+// exponential backoff so that unreachable registries don't slow down every reconcile.
+type ImageInspector struct {
+	transport http.RoundTripper
+	mutex     sync.Mutex
+	cache     map[string]*ImageMetadata
+	tags      map[string]*resolvedTag
+	failures  map[string]*inspectFailure
+	now       func() time.Time
+}
+
+type resolvedTag struct {
+	digest    string
+	expiresAt time.Time
+}
+
+type inspectFailure struct {
+	err     error
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// NewImageInspector ...
+func NewImageInspector(transport http.RoundTripper) *ImageInspector {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &ImageInspector{
+		transport: &timeoutTransport{base: transport, timeout: registryTimeout},
+		cache:     map[string]*ImageMetadata{},
+		tags:      map[string]*resolvedTag{},
+		failures:  map[string]*inspectFailure{},
+		now:       time.Now,
+	}
+}
+
+// timeoutTransport cancels requests that don't complete within the timeout, as http.Client does. The registry
+// client only accepts a transport, so the timeout can't be set on a client.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a request once its response is read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// Inspect returns the metadata of the image, authenticating to its registry with the given pull secrets. An image
+// that failed to be inspected returns the same error until its backoff expires or its pull secrets change.
+func (i *ImageInspector) Inspect(image string, pullSecrets []corev1.Secret) (*ImageMetadata, error) {
+	key := getInspectKey(image, pullSecrets)
+	i.mutex.Lock()
+	failure := i.failures[key]
+	i.mutex.Unlock()
+	if failure != nil && i.now().Before(failure.retryAt) {
+		return nil, failure.err
+	}
+
+	metadata, err := i.inspect(image, pullSecrets)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if err == nil {
+		delete(i.failures, key)
+		return metadata, nil
+	}
+	backoff := minInspectBackoff
+	if failure != nil {
+		backoff = failure.backoff * 2
+		if backoff > maxInspectBackoff {
+			backoff = maxInspectBackoff
+		}
+	}
+	if len(i.failures) >= maxCachedImages {
+		i.failures = map[string]*inspectFailure{}
+	}
+	i.failures[key] = &inspectFailure{err: err, backoff: backoff, retryAt: i.now().Add(backoff)}
+	return nil, err
+}
+
+// GetRetryInterval returns how long until the image is inspected again after a failure, or 0 if it didn't fail
+func (i *ImageInspector) GetRetryInterval(image string, pullSecrets []corev1.Secret) time.Duration {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	failure := i.failures[getInspectKey(image, pullSecrets)]
+	if failure == nil {
+		return 0
+	}
+	if interval := failure.retryAt.Sub(i.now()); interval > 0 {
+		return interval
+	}
+	return 0
+}
+
+// getInspectKey identifies the image along with the credentials it is read with
+func getInspectKey(image string, pullSecrets []corev1.Secret) string {
+	hash := fnv.New32a()
+	for _, secret := range pullSecrets {
+		hash.Write([]byte(secret.Namespace + "/" + secret.Name))
+		keys := []string{}
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			hash.Write([]byte(key))
+			hash.Write(secret.Data[key])
+		}
+	}
+	return fmt.Sprintf("%s#%08x", image, hash.Sum32())
+}
+
+func (i *ImageInspector) inspect(image string, pullSecrets []corev1.Secret) (*ImageMetadata, error) {
+	ref, err := imagename.ParseReference(image, imagename.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+	// Images pinned by digest, or whose tag was resolved recently, don't need the registry
+	key := getInspectKey(image, pullSecrets)
+	digest := i.getResolvedTag(key)
+	if d, ok := ref.(imagename.Digest); ok {
+		digest = d.DigestStr()
+	}
+	if metadata := i.getCached(digest); metadata != nil {
+		return metadata, nil
+	}
+
+	options := []remote.Option{remote.WithTransport(i.transport), remote.WithAuthFromKeychain(newPullSecretKeychain(pullSecrets))}
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ref.(imagename.Tag); ok {
+		i.setResolvedTag(key, desc.Digest.String())
+	}
+	if metadata := i.getCached(desc.Digest.String()); metadata != nil {
+		return metadata, nil
+	}
+
+	metadata := &ImageMetadata{Digest: desc.Digest.String()}
+	var img v1.Image
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+		// The images of every platform are built from the same stack, so the config is read from the first one
+		for _, m := range manifest.Manifests {
+			if m.Platform == nil || m.Platform.OS != "linux" || m.Platform.Architecture == "" || m.Platform.Architecture == "unknown" {
+				continue
+			}
+			if img == nil {
+				if img, err = index.Image(m.Digest); err != nil {
+					return nil, err
+				}
+			}
+			metadata.Architectures = appendUnique(metadata.Architectures, m.Platform.Architecture)
+		}
+		if img == nil {
+			return nil, fmt.Errorf("Image index %s has no linux image", image)
+		}
+	default:
+		if img, err = desc.Image(); err != nil {
+			return nil, err
+		}
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata.Architectures) == 0 && config.Architecture != "" {
+		metadata.Architectures = []string{config.Architecture}
+	}
+	metadata.StackID = config.Config.Labels[StackIDLabel]
+	metadata.StackVersion = config.Config.Labels[StackVersionLabel]
+	metadata.Labels = config.Config.Labels
+	for port := range config.Config.ExposedPorts {
+		parts := strings.SplitN(port, "/", 2)
+		if len(parts) == 2 && parts[1] != "tcp" {
+			continue
+		}
+		if p, err := strconv.ParseInt(parts[0], 10, 32); err == nil {
+			metadata.Ports = append(metadata.Ports, int32(p))
+		}
+	}
+	sort.Slice(metadata.Ports, func(a, b int) bool {
+		return metadata.Ports[a] < metadata.Ports[b]
+	})
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if len(i.cache) >= maxCachedImages {
+		i.cache = map[string]*ImageMetadata{}
+	}
+	i.cache[metadata.Digest] = metadata
+	return metadata, nil
+}
+
+// ResolveDigest returns the digest the image currently refers to. Images pinned by digest are not looked up, and
+// tags resolved less than tagDigestTTL ago are not looked up again.
+func (i *ImageInspector) ResolveDigest(image string, pullSecrets []corev1.Secret) (string, error) {
+	ref, err := imagename.ParseReference(image, imagename.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+	if digest, ok := ref.(imagename.Digest); ok {
+		return digest.DigestStr(), nil
+	}
+	key := getInspectKey(image, pullSecrets)
+	if digest := i.getResolvedTag(key); digest != "" {
+		return digest, nil
+	}
+	desc, err := remote.Get(ref, remote.WithTransport(i.transport), remote.WithAuthFromKeychain(newPullSecretKeychain(pullSecrets)))
+	if err != nil {
+		return "", err
+	}
+	i.setResolvedTag(key, desc.Digest.String())
+	return desc.Digest.String(), nil
+}
+
+// getResolvedTag returns the digest the tag was last resolved to, unless it expired
+func (i *ImageInspector) getResolvedTag(key string) string {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if tag := i.tags[key]; tag != nil && i.now().Before(tag.expiresAt) {
+		return tag.digest
+	}
+	return ""
+}
+
+func (i *ImageInspector) setResolvedTag(key string, digest string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if len(i.tags) >= maxCachedImages {
+		i.tags = map[string]*resolvedTag{}
+	}
+	i.tags[key] = &resolvedTag{digest: digest, expiresAt: i.now().Add(tagDigestTTL)}
+}
+
+// PinImageDigest returns the reference of the repository of the image pinned to the digest
+func PinImageDigest(image string, digest string) (string, error) {
+	ref, err := imagename.ParseReference(image, imagename.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+	pinned, err := imagename.NewDigest(ref.Context().String()+"@"+digest, imagename.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+	return pinned.String(), nil
+}
+
+func (i *ImageInspector) getCached(digest string) *ImageMetadata {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.cache[digest]
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// pullSecretKeychain resolves the credentials of registries from image pull secrets
+type pullSecretKeychain struct {
+	entries map[string]dockerConfigEntry
+}
+
+func newPullSecretKeychain(secrets []corev1.Secret) *pullSecretKeychain {
+	keychain := &pullSecretKeychain{entries: map[string]dockerConfigEntry{}}
+	for _, secret := range secrets {
+		entries := map[string]dockerConfigEntry{}
+		if data, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+			var config struct {
+				Auths map[string]dockerConfigEntry `json:"auths"`
+			}
+			if json.Unmarshal(data, &config) == nil {
+				entries = config.Auths
+			}
+		} else if data, ok := secret.Data[corev1.DockerConfigKey]; ok {
+			json.Unmarshal(data, &entries)
+		}
+		for registry, entry := range entries {
+			registry = normalizeRegistry(registry)
+			// The first pull secret listing a registry wins, as for the kubelet
+			if _, ok := keychain.entries[registry]; !ok {
+				keychain.entries[registry] = entry
+			}
+		}
+	}
+	return keychain
+}
+
+// Resolve ...
+func (k *pullSecretKeychain) Resolve(registry imagename.Registry) (authn.Authenticator, error) {
+	entry, ok := k.entries[normalizeRegistry(registry.RegistryStr())]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+	if entry.Username == "" && entry.Auth != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(entry.Auth); err == nil {
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				entry.Username, entry.Password = parts[0], parts[1]
+			}
+		}
+	}
+	return &authn.Basic{Username: entry.Username, Password: entry.Password}, nil
+}
+
+// normalizeRegistry strips the scheme and path of the registries in docker configs and maps the aliases of
+// Docker Hub to the same registry
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	registry = strings.SplitN(registry, "/", 2)[0]
+	switch registry {
+	case "docker.io", "registry-1.docker.io", imagename.DefaultRegistry:
+		return imagename.DefaultRegistry
+	}
+	return registry
+}
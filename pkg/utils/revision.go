@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RevisionOfLabel labels the ControllerRevisions holding the specs of an application with its name
+const RevisionOfLabel = "appsody.dev/revision-of"
+
+// DefaultRevisionHistoryLimit is the number of revisions kept when revisionHistoryLimit is not set
+const DefaultRevisionHistoryLimit = 10
+
+// GetRevisionSpec returns the spec recorded in the revisions of the application. The image is pinned to its
+// deployed digest, so restoring the revision deploys the same image even after its tag moved.
+func GetRevisionSpec(cr *appsodyv1alpha1.AppsodyApplication) *appsodyv1alpha1.AppsodyApplicationSpec {
+	spec := cr.Spec.DeepCopy()
+	spec.RollbackTo = nil
+	if image := GetImage(cr); image != spec.ApplicationImage {
+		spec.ApplicationImage = image
+		spec.ImagePolicy = nil
+	}
+	return spec
+}
+
+// GetRevisionName returns the name of the ControllerRevision of the application holding the given spec
+func GetRevisionName(cr *appsodyv1alpha1.AppsodyApplication, data []byte) string {
+	return fmt.Sprintf("%s-%x", cr.Name, sha256.Sum256(data))[:len(cr.Name)+11]
+}
+
+// CustomizeControllerRevision ...
+func CustomizeControllerRevision(rev *appsv1.ControllerRevision, cr *appsodyv1alpha1.AppsodyApplication, data []byte, revision int64) {
+	rev.Labels = GetLabels(cr)
+	rev.Labels[RevisionOfLabel] = cr.Name
+	rev.Data = runtime.RawExtension{Raw: data}
+	rev.Revision = revision
+}
+
+// GetRevisionHistoryLimit ...
+func GetRevisionHistoryLimit(cr *appsodyv1alpha1.AppsodyApplication) int {
+	if cr.Spec.RevisionHistoryLimit == nil || *cr.Spec.RevisionHistoryLimit < 1 {
+		return DefaultRevisionHistoryLimit
+	}
+	return int(*cr.Spec.RevisionHistoryLimit)
+}
+
+// SortRevisions sorts the revisions from the oldest to the most recent
+func SortRevisions(revisions []appsv1.ControllerRevision) {
+	sort.Slice(revisions, func(a, b int) bool {
+		return revisions[a].Revision < revisions[b].Revision
+	})
+}
+
+// GetRevisionStatus ...
+func GetRevisionStatus(rev *appsv1.ControllerRevision) (appsodyv1alpha1.RevisionStatus, error) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{}
+	if err := json.Unmarshal(rev.Data.Raw, &spec); err != nil {
+		return appsodyv1alpha1.RevisionStatus{}, fmt.Errorf("Failed to read the spec of revision %s: %v", rev.Name, err)
+	}
+	return appsodyv1alpha1.RevisionStatus{
+		Revision:  rev.Revision,
+		Name:      rev.Name,
+		Image:     spec.ApplicationImage,
+		CreatedAt: rev.CreationTimestamp,
+	}, nil
+}
@@ -1,250 +1,1513 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
 	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	servingv1beta1 "github.com/knative/serving/pkg/apis/serving/v1beta1"
+	imagev1 "github.com/openshift/api/image/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// KnativeServingVersions lists the Knative Serving group versions the operator can generate, newest first
+var KnativeServingVersions = []string{
+	"serving.knative.dev/v1",
+	"serving.knative.dev/v1beta1",
+	servingv1alpha1.SchemeGroupVersion.String(),
+}
+
+// MetadataPrefixesAllowEnvVar names the environment variable holding the comma separated label and
+// annotation prefixes that are propagated from the AppsodyApplication. All prefixes are allowed when unset.
+const MetadataPrefixesAllowEnvVar = "METADATA_PREFIXES_ALLOW"
+
+// MetadataPrefixesDenyEnvVar names the environment variable holding the comma separated label and
+// annotation prefixes that are never propagated from the AppsodyApplication
+const MetadataPrefixesDenyEnvVar = "METADATA_PREFIXES_DENY"
+
+// UnknownStackPolicyEnvVar names the environment variable holding how the operator handles applications of
+// stacks without defaults: strict, fallback (default) or allowlist
+const UnknownStackPolicyEnvVar = "UNKNOWN_STACK_POLICY"
+
+// AllowedStacksEnvVar names the environment variable holding the comma separated stacks allowed by the
+// allowlist policy
+const AllowedStacksEnvVar = "ALLOWED_STACKS"
+
+// KnativePodSpecFeaturesEnvVar names the environment variable holding the comma separated Knative Serving pod spec
+// features enabled on the cluster: affinity, nodeselector and tolerations, matching the kubernetes.podspec-* flags
+const KnativePodSpecFeaturesEnvVar = "KNATIVE_PODSPEC_FEATURES"
+
+// UnknownStackPolicy ...
+type UnknownStackPolicy string
+
+const (
+	// UnknownStackPolicyStrict rejects applications of stacks without defaults
+	UnknownStackPolicyStrict UnknownStackPolicy = "strict"
+	// UnknownStackPolicyFallback uses the defaults of the generic stack for applications of stacks without defaults
+	UnknownStackPolicyFallback UnknownStackPolicy = "fallback"
+	// UnknownStackPolicyAllowlist rejects applications of stacks which are not allowed explicitly
+	UnknownStackPolicyAllowlist UnknownStackPolicy = "allowlist"
+)
+
+// GetUnknownStackPolicy returns the policy set on the operator for applications of stacks without defaults
+func GetUnknownStackPolicy() (UnknownStackPolicy, error) {
+	switch policy := UnknownStackPolicy(os.Getenv(UnknownStackPolicyEnvVar)); policy {
+	case "":
+		return UnknownStackPolicyFallback, nil
+	case UnknownStackPolicyStrict, UnknownStackPolicyFallback, UnknownStackPolicyAllowlist:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("Unknown %s `%v`, must be one of %v, %v or %v", UnknownStackPolicyEnvVar, policy,
+			UnknownStackPolicyStrict, UnknownStackPolicyFallback, UnknownStackPolicyAllowlist)
+	}
+}
+
+// IsStackAllowed returns whether the allowlist set on the operator contains the stack
+func IsStackAllowed(stack string) bool {
+	for _, s := range splitPrefixes(os.Getenv(AllowedStacksEnvVar)) {
+		if s == stack {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNamespaceAllowed returns whether the annotation of the namespace, a comma separated list of namespaces or `*`,
+// allows the objects of namespace from to manage its applications. A namespace always allows itself.
+func IsNamespaceAllowed(ns *corev1.Namespace, annotation string, from string) bool {
+	if ns.Name == from {
+		return true
+	}
+	for _, n := range splitPrefixes(ns.Annotations[annotation]) {
+		if n == from || n == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultDeniedPrefixes are never propagated because they describe the AppsodyApplication itself
+var defaultDeniedPrefixes = []string{"kubectl.kubernetes.io/"}
+
 // GetLabels ...
 func GetLabels(cr *appsodyv1alpha1.AppsodyApplication) map[string]string {
-	labels := map[string]string{
-		"app.kubernetes.io/name":       cr.Name,
-		"app.kubernetes.io/managed-by": "appsody-operator",
+	labels := filterMetadata(cr.Labels)
+	labels["app.kubernetes.io/name"] = cr.Name
+	labels["app.kubernetes.io/managed-by"] = "appsody-operator"
+	return labels
+}
+
+// GetAnnotations ...
+func GetAnnotations(cr *appsodyv1alpha1.AppsodyApplication) map[string]string {
+	return filterMetadata(cr.Annotations)
+}
+
+// CustomizeObjectMeta merges the labels and annotations propagated from the AppsodyApplication into the
+// metadata of a generated resource, keeping the ones added by other controllers
+func CustomizeObjectMeta(meta *metav1.ObjectMeta, cr *appsodyv1alpha1.AppsodyApplication) {
+	meta.Labels = mergeMaps(meta.Labels, GetLabels(cr))
+	meta.Annotations = mergeMaps(meta.Annotations, GetAnnotations(cr))
+}
+
+func mergeMaps(dst map[string]string, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]string{}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func filterMetadata(metadata map[string]string) map[string]string {
+	allowed := splitPrefixes(os.Getenv(MetadataPrefixesAllowEnvVar))
+	denied := append(splitPrefixes(os.Getenv(MetadataPrefixesDenyEnvVar)), defaultDeniedPrefixes...)
+
+	filtered := map[string]string{}
+	for k, v := range metadata {
+		if (len(allowed) == 0 || hasAnyPrefix(k, allowed)) && !hasAnyPrefix(k, denied) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+func splitPrefixes(value string) []string {
+	prefixes := []string{}
+	for _, prefix := range strings.Split(value, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomizeRoute ...
+func CustomizeRoute(route *routev1.Route, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&route.ObjectMeta, cr)
+	route.Spec.To.Kind = "Service"
+	route.Spec.To.Name = cr.Name
+	weight := int32(100)
+	route.Spec.To.Weight = &weight
+	if route.Spec.Port == nil {
+		route.Spec.Port = &routev1.RoutePort{}
+	}
+	route.Spec.Port.TargetPort = intstr.FromInt(int(cr.Spec.Service.Port))
+}
+
+// ErrorIsNoMatchesForKind ...
+func ErrorIsNoMatchesForKind(err error, kind string, version string) bool {
+	return strings.HasPrefix(err.Error(), fmt.Sprintf("no matches for kind \"%s\" in version \"%s\"", kind, version))
+}
+
+// CustomizeService ...
+func CustomizeService(svc *corev1.Service, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&svc.ObjectMeta, cr)
+	if len(svc.Spec.Ports) == 0 {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{})
+	}
+	svc.Spec.Ports[0].Port = cr.Spec.Service.Port
+	svc.Spec.Ports[0].TargetPort = intstr.FromInt(int(cr.Spec.Service.Port))
+	svc.Spec.Type = *cr.Spec.Service.Type
+	svc.Spec.Selector = map[string]string{
+		"app.kubernetes.io/name": cr.Name,
+	}
+}
+
+// CustomizePodSpec ...
+func CustomizePodSpec(pts *corev1.PodTemplateSpec, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&pts.ObjectMeta, cr)
+	// The Kubernetes API the operator is built against only selects seccomp profiles through annotations
+	setPodAnnotation(&pts.ObjectMeta, SeccompPodAnnotation, GetSeccompProfile(cr))
+	setPodAnnotation(&pts.ObjectMeta, ConfigHashAnnotation, cr.Status.ConfigHash)
+	customizeAppPodSpec(&pts.Spec, cr)
+	pts.Spec.Containers[0].Name = "app"
+	pts.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	pts.Spec.DNSPolicy = corev1.DNSClusterFirst
+}
+
+// customizeAppPodSpec sets the parts of the pod spec that are shared by every workload kind,
+// including the revision template of a Knative Service
+func customizeAppPodSpec(ps *corev1.PodSpec, cr *appsodyv1alpha1.AppsodyApplication) {
+	if len(ps.Containers) == 0 {
+		ps.Containers = append(ps.Containers, corev1.Container{})
+	}
+	if len(ps.Containers[0].Ports) == 0 {
+		ps.Containers[0].Ports = append(ps.Containers[0].Ports, corev1.ContainerPort{})
+	}
+	ps.Containers[0].Ports[0].ContainerPort = cr.Spec.Service.Port
+	ps.Containers[0].Image = GetImage(cr)
+	ps.Containers[0].Resources = *cr.Spec.ResourceConstraints
+	ps.Containers[0].ReadinessProbe = cr.Spec.ReadinessProbe
+	ps.Containers[0].LivenessProbe = cr.Spec.LivenessProbe
+	ps.Containers[0].VolumeMounts = cr.Spec.VolumeMounts
+	ps.Containers[0].ImagePullPolicy = *cr.Spec.PullPolicy
+	ps.Containers[0].Env = cr.Spec.Env
+	ps.Containers[0].EnvFrom = cr.Spec.EnvFrom
+	ps.Volumes = cr.Spec.Volumes
+	customizeConfig(ps, cr)
+	ps.ImagePullSecrets = GetPullSecrets(cr)
+
+	if cr.Spec.ServiceAccountName != nil && *cr.Spec.ServiceAccountName != "" {
+		ps.ServiceAccountName = *cr.Spec.ServiceAccountName
+	} else {
+		ps.ServiceAccountName = cr.Name
+	}
+
+	ps.Affinity = nil
+	if len(GetArchitectures(cr)) > 0 || (cr.Spec.Scheduling != nil && cr.Spec.Scheduling.SpreadReplicas != "") {
+		ps.Affinity = &corev1.Affinity{}
+		CustomizeAffinity(ps.Affinity, cr)
+	}
+	CustomizeScheduling(ps, cr)
+	ps.SecurityContext, ps.Containers[0].SecurityContext = GetSecurityContexts(cr)
+}
+
+// ConfigVolumeName is the name of the volume holding the configuration files of the application
+const ConfigVolumeName = "appsody-config"
+
+// ConfigOfLabel labels the ConfigMaps rendered from the configuration of an application with its name
+const ConfigOfLabel = "appsody.dev/config-of"
+
+// GetConfigData renders the inline configuration of the application into the data of a ConfigMap
+func GetConfigData(cr *appsodyv1alpha1.AppsodyApplication) map[string]string {
+	data := map[string]string{}
+	if cr.Spec.Config == nil {
+		return data
+	}
+	for i, file := range cr.Spec.Config.Files {
+		if file.ConfigMapKeyRef == nil && file.SecretKeyRef == nil {
+			data[configFileKey(i)] = file.Content
+		}
+	}
+	for name, value := range cr.Spec.Config.Env {
+		data["env."+name] = value
+	}
+	return data
+}
+
+// GetConfigMapName returns the name of the ConfigMap rendered from the inline configuration of the application,
+// or an empty string if there is none. The name contains a hash of the content so that changes roll out.
+func GetConfigMapName(cr *appsodyv1alpha1.AppsodyApplication) string {
+	data := GetConfigData(cr)
+	if len(data) == 0 {
+		return ""
+	}
+	content, _ := json.Marshal(data)
+	hash := fnv.New32a()
+	hash.Write(content)
+	return fmt.Sprintf("%s-config-%08x", cr.Name, hash.Sum32())
+}
+
+// CustomizeConfigMap ...
+func CustomizeConfigMap(cm *corev1.ConfigMap, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&cm.ObjectMeta, cr)
+	cm.Labels[ConfigOfLabel] = cr.Name
+	cm.Data = GetConfigData(cr)
+}
+
+// ValidateConfig ...
+func ValidateConfig(cr *appsodyv1alpha1.AppsodyApplication) error {
+	if cr.Spec.Config == nil {
+		return nil
+	}
+	paths := map[string]bool{}
+	for _, file := range cr.Spec.Config.Files {
+		if !strings.HasPrefix(file.Path, "/") {
+			return fmt.Errorf("Path of configuration file `%v` must be absolute", file.Path)
+		}
+		if paths[file.Path] {
+			return fmt.Errorf("Configuration file path `%v` is used more than once", file.Path)
+		}
+		paths[file.Path] = true
+		if file.ConfigMapKeyRef != nil && file.SecretKeyRef != nil || (file.ConfigMapKeyRef != nil || file.SecretKeyRef != nil) && file.Content != "" {
+			return fmt.Errorf("Configuration file `%v` must set only one of content, configMapKeyRef and secretKeyRef", file.Path)
+		}
+	}
+	return nil
+}
+
+func configFileKey(index int) string {
+	return fmt.Sprintf("file.%d", index)
+}
+
+// customizeConfig mounts every configuration file at its path through a single projected volume and
+// adds the inline environment variables, without modifying the slices of the spec
+func customizeConfig(ps *corev1.PodSpec, cr *appsodyv1alpha1.AppsodyApplication) {
+	if cr.Spec.Config == nil {
+		return
+	}
+	container := &ps.Containers[0]
+
+	if len(cr.Spec.Config.Env) > 0 {
+		names := []string{}
+		for name := range cr.Spec.Config.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		env := append([]corev1.EnvVar{}, container.Env...)
+		for _, name := range names {
+			env = append(env, corev1.EnvVar{
+				Name: name,
+				ValueFrom: &corev1.EnvVarSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: GetConfigMapName(cr)},
+						Key:                  "env." + name,
+					},
+				},
+			})
+		}
+		container.Env = env
+	}
+
+	if len(cr.Spec.Config.Files) == 0 {
+		return
+	}
+	projected := &corev1.ProjectedVolumeSource{}
+	inline := &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: GetConfigMapName(cr)}}
+	mounts := append([]corev1.VolumeMount{}, container.VolumeMounts...)
+	for i, file := range cr.Spec.Config.Files {
+		item := corev1.KeyToPath{Key: configFileKey(i), Path: configFileKey(i)}
+		switch {
+		case file.ConfigMapKeyRef != nil:
+			item.Key = file.ConfigMapKeyRef.Key
+			projected.Sources = append(projected.Sources, corev1.VolumeProjection{ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: file.ConfigMapKeyRef.LocalObjectReference,
+				Items:                []corev1.KeyToPath{item},
+				Optional:             file.ConfigMapKeyRef.Optional,
+			}})
+		case file.SecretKeyRef != nil:
+			item.Key = file.SecretKeyRef.Key
+			projected.Sources = append(projected.Sources, corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+				LocalObjectReference: file.SecretKeyRef.LocalObjectReference,
+				Items:                []corev1.KeyToPath{item},
+				Optional:             file.SecretKeyRef.Optional,
+			}})
+		default:
+			inline.Items = append(inline.Items, item)
+		}
+		mounts = append(mounts, corev1.VolumeMount{Name: ConfigVolumeName, MountPath: file.Path, SubPath: configFileKey(i), ReadOnly: true})
+	}
+	if len(inline.Items) > 0 {
+		projected.Sources = append([]corev1.VolumeProjection{{ConfigMap: inline}}, projected.Sources...)
+	}
+	container.VolumeMounts = mounts
+	ps.Volumes = append(append([]corev1.Volume{}, ps.Volumes...), corev1.Volume{
+		Name:         ConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{Projected: projected},
+	})
+}
+
+// ConfigHashAnnotation is the pod template annotation holding the hash of the referenced ConfigMaps and Secrets.
+// Changing it rolls out the application.
+const ConfigHashAnnotation = "appsody.dev/config-hash"
+
+// GetConfigReferences returns the ConfigMaps and Secrets referenced through env, envFrom, volumes and config files, sorted by
+// kind and name, leaving out the ones listed in rolloutExclusions
+func GetConfigReferences(cr *appsodyv1alpha1.AppsodyApplication) []appsodyv1alpha1.ConfigReference {
+	seen := map[appsodyv1alpha1.ConfigReference]bool{}
+	for _, ref := range cr.Spec.RolloutExclusions {
+		seen[ref] = true
+	}
+
+	references := []appsodyv1alpha1.ConfigReference{}
+	add := func(kind appsodyv1alpha1.ConfigReferenceKind, name string) {
+		ref := appsodyv1alpha1.ConfigReference{Kind: kind, Name: name}
+		if name != "" && !seen[ref] {
+			seen[ref] = true
+			references = append(references, ref)
+		}
+	}
+
+	for _, v := range cr.Spec.EnvFrom {
+		if v.ConfigMapRef != nil {
+			add(appsodyv1alpha1.ConfigReferenceKindConfigMap, v.ConfigMapRef.Name)
+		}
+		if v.SecretRef != nil {
+			add(appsodyv1alpha1.ConfigReferenceKindSecret, v.SecretRef.Name)
+		}
+	}
+	for _, v := range cr.Spec.Env {
+		if v.ValueFrom == nil {
+			continue
+		}
+		if v.ValueFrom.ConfigMapKeyRef != nil {
+			add(appsodyv1alpha1.ConfigReferenceKindConfigMap, v.ValueFrom.ConfigMapKeyRef.Name)
+		}
+		if v.ValueFrom.SecretKeyRef != nil {
+			add(appsodyv1alpha1.ConfigReferenceKindSecret, v.ValueFrom.SecretKeyRef.Name)
+		}
+	}
+	for _, v := range cr.Spec.Volumes {
+		if v.ConfigMap != nil {
+			add(appsodyv1alpha1.ConfigReferenceKindConfigMap, v.ConfigMap.Name)
+		}
+		if v.Secret != nil {
+			add(appsodyv1alpha1.ConfigReferenceKindSecret, v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(appsodyv1alpha1.ConfigReferenceKindConfigMap, source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					add(appsodyv1alpha1.ConfigReferenceKindSecret, source.Secret.Name)
+				}
+			}
+		}
+	}
+
+	if cr.Spec.Config != nil {
+		for _, file := range cr.Spec.Config.Files {
+			if file.ConfigMapKeyRef != nil {
+				add(appsodyv1alpha1.ConfigReferenceKindConfigMap, file.ConfigMapKeyRef.Name)
+			}
+			if file.SecretKeyRef != nil {
+				add(appsodyv1alpha1.ConfigReferenceKindSecret, file.SecretKeyRef.Name)
+			}
+		}
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].Kind != references[j].Kind {
+			return references[i].Kind < references[j].Kind
+		}
+		return references[i].Name < references[j].Name
+	})
+	return references
+}
+
+// IsConfigReferenced ...
+func IsConfigReferenced(cr *appsodyv1alpha1.AppsodyApplication, kind appsodyv1alpha1.ConfigReferenceKind, name string) bool {
+	for _, ref := range GetConfigReferences(cr) {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setPodAnnotation sets the annotation when the value is not empty and removes it otherwise
+func setPodAnnotation(meta *metav1.ObjectMeta, key string, value string) {
+	if value != "" {
+		meta.Annotations = mergeMaps(meta.Annotations, map[string]string{key: value})
+	} else {
+		delete(meta.Annotations, key)
+	}
+}
+
+// SeccompPodAnnotation is the pod annotation selecting the seccomp profile of all containers of the pod
+const SeccompPodAnnotation = "seccomp.security.alpha.kubernetes.io/pod"
+
+// GetSecurityContexts returns the pod and container security contexts, with the settings of the
+// selected profile filled in where they are not set explicitly
+func GetSecurityContexts(cr *appsodyv1alpha1.AppsodyApplication) (*corev1.PodSecurityContext, *corev1.SecurityContext) {
+	security := cr.Spec.SecurityContext
+	if security == nil {
+		return nil, nil
+	}
+	pod := security.Pod.DeepCopy()
+	container := security.Container.DeepCopy()
+
+	if security.Profile == appsodyv1alpha1.SecurityProfileRestricted {
+		if pod == nil {
+			pod = &corev1.PodSecurityContext{}
+		}
+		if container == nil {
+			container = &corev1.SecurityContext{}
+		}
+		if pod.RunAsNonRoot == nil {
+			runAsNonRoot := true
+			pod.RunAsNonRoot = &runAsNonRoot
+		}
+		if container.AllowPrivilegeEscalation == nil {
+			allowPrivilegeEscalation := false
+			container.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+		}
+		if container.Capabilities == nil {
+			container.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+		}
+	}
+	return pod, container
+}
+
+// GetSeccompProfile returns the value of the seccomp pod annotation, or an empty string if no profile is selected
+func GetSeccompProfile(cr *appsodyv1alpha1.AppsodyApplication) string {
+	security := cr.Spec.SecurityContext
+	if security == nil {
+		return ""
+	}
+	if security.SeccompProfile == nil {
+		if security.Profile == appsodyv1alpha1.SecurityProfileRestricted {
+			return "runtime/default"
+		}
+		return ""
+	}
+	switch security.SeccompProfile.Type {
+	case appsodyv1alpha1.SeccompProfileTypeUnconfined:
+		return "unconfined"
+	case appsodyv1alpha1.SeccompProfileTypeLocalhost:
+		if security.SeccompProfile.LocalhostProfile != nil {
+			return "localhost/" + *security.SeccompProfile.LocalhostProfile
+		}
+		return ""
+	default:
+		return "runtime/default"
+	}
+}
+
+// CustomizeScheduling ...
+func CustomizeScheduling(ps *corev1.PodSpec, cr *appsodyv1alpha1.AppsodyApplication) {
+	if cr.Spec.Scheduling == nil {
+		ps.NodeSelector = nil
+		ps.Tolerations = nil
+		ps.PriorityClassName = ""
+		return
+	}
+	ps.NodeSelector = cr.Spec.Scheduling.NodeSelector
+	ps.Tolerations = cr.Spec.Scheduling.Tolerations
+	ps.PriorityClassName = cr.Spec.Scheduling.PriorityClassName
+}
+
+// CustomizePersistence ...
+func CustomizePersistence(statefulSet *appsv1.StatefulSet, cr *appsodyv1alpha1.AppsodyApplication) {
+	if len(statefulSet.Spec.VolumeClaimTemplates) == 0 {
+		statefulSet.Spec.VolumeClaimTemplates = GetVolumeClaimTemplates(cr)
+	}
+
+	container := &statefulSet.Spec.Template.Spec.Containers[0]
+	for _, pvc := range statefulSet.Spec.VolumeClaimTemplates {
+		mountPath := getStorageMountPath(cr, pvc.Name)
+		if pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == corev1.PersistentVolumeBlock {
+			found := false
+			for _, d := range container.VolumeDevices {
+				if d.Name == pvc.Name {
+					found = true
+				}
+			}
+			if !found {
+				container.VolumeDevices = append(container.VolumeDevices, corev1.VolumeDevice{Name: pvc.Name, DevicePath: mountPath})
+			}
+			continue
+		}
+
+		found := false
+		for _, v := range container.VolumeMounts {
+			if v.Name == pvc.Name {
+				found = true
+			}
+		}
+		if !found {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: pvc.Name, MountPath: mountPath})
+		}
+	}
+}
+
+// GetVolumeClaimTemplates returns the claim templates requested by the storage section of the CR. The single
+// volume configured through `size` or `volumeClaimTemplate` comes first and keeps its `pvc` claim name, so
+// existing PVCs stay bound when more volumes are added to the list.
+func GetVolumeClaimTemplates(cr *appsodyv1alpha1.AppsodyApplication) []corev1.PersistentVolumeClaim {
+	var templates []corev1.PersistentVolumeClaim
+	if cr.Spec.Storage == nil {
+		return templates
+	}
+	if cr.Spec.Storage.VolumeClaimTemplate != nil {
+		templates = append(templates, *cr.Spec.Storage.VolumeClaimTemplate)
+	} else if cr.Spec.Storage.Size != "" {
+		templates = append(templates, newVolumeClaimTemplate(cr, appsodyv1alpha1.AppsodyApplicationVolume{
+			Name: "pvc",
+			Size: cr.Spec.Storage.Size,
+		}))
+	}
+
+	for _, v := range cr.Spec.Storage.Volumes {
+		templates = append(templates, newVolumeClaimTemplate(cr, v))
+	}
+	return templates
+}
+
+func newVolumeClaimTemplate(cr *appsodyv1alpha1.AppsodyApplication, v appsodyv1alpha1.AppsodyApplicationVolume) corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v.Name,
+			Namespace: cr.Namespace,
+			Labels:    GetLabels(cr),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(v.Size),
+				},
+			},
+			AccessModes:      v.AccessModes,
+			StorageClassName: v.StorageClassName,
+			VolumeMode:       v.VolumeMode,
+		},
+	}
+	if len(pvc.Spec.AccessModes) == 0 {
+		pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	return pvc
+}
+
+func getStorageMountPath(cr *appsodyv1alpha1.AppsodyApplication, claimName string) string {
+	for _, v := range cr.Spec.Storage.Volumes {
+		if v.Name == claimName {
+			return v.MountPath
+		}
+	}
+	return cr.Spec.Storage.MountPath
+}
+
+// GetRequestedStorageSize returns the size requested in the CR for the claim template with the given name
+func GetRequestedStorageSize(cr *appsodyv1alpha1.AppsodyApplication, claimName string) (resource.Quantity, bool) {
+	if cr.Spec.Storage == nil {
+		return resource.Quantity{}, false
+	}
+	for _, template := range GetVolumeClaimTemplates(cr) {
+		if template.Name == claimName {
+			size, ok := template.Spec.Resources.Requests[corev1.ResourceStorage]
+			return size, ok
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+// HasVolumeClaimTemplates checks whether the StatefulSet was created with the claim templates requested by the CR.
+// Claim templates can't be changed once the StatefulSet exists.
+func HasVolumeClaimTemplates(statefulSet *appsv1.StatefulSet, cr *appsodyv1alpha1.AppsodyApplication) bool {
+	templates := GetVolumeClaimTemplates(cr)
+	if len(templates) != len(statefulSet.Spec.VolumeClaimTemplates) {
+		return false
+	}
+	for i := range templates {
+		if templates[i].Name != statefulSet.Spec.VolumeClaimTemplates[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateStorage ...
+func ValidateStorage(cr *appsodyv1alpha1.AppsodyApplication) error {
+	storage := cr.Spec.Storage
+	if storage.Size == "" && storage.VolumeClaimTemplate == nil && len(storage.Volumes) == 0 {
+		return fmt.Errorf("storage requires `size`, `volumeClaimTemplate` or at least one entry in `volumes`")
+	}
+	if storage.Size != "" {
+		if _, err := resource.ParseQuantity(storage.Size); err != nil {
+			return fmt.Errorf("invalid storage size `%s`: %v", storage.Size, err)
+		}
+	}
+
+	for _, v := range storage.Volumes {
+		if _, err := resource.ParseQuantity(v.Size); err != nil {
+			return fmt.Errorf("invalid size `%s` for storage volume `%s`: %v", v.Size, v.Name, err)
+		}
+		if v.MountPath == "" {
+			return fmt.Errorf("storage volume `%s` requires a `mountPath`", v.Name)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, v := range cr.Spec.Volumes {
+		names[v.Name] = true
+	}
+	for _, pvc := range GetVolumeClaimTemplates(cr) {
+		if names[pvc.Name] {
+			return fmt.Errorf("storage volume `%s` is defined more than once", pvc.Name)
+		}
+		names[pvc.Name] = true
+	}
+	return nil
+}
+
+// IsVolumeClaimOf returns whether the PVC was created from the claim template for one of the current replicas of
+// the StatefulSet. Claims are named `<template>-<statefulset>-<ordinal>`, so the ordinal must be checked exactly for
+// claims of StatefulSets whose names share a prefix not to match.
+func IsVolumeClaimOf(pvc *corev1.PersistentVolumeClaim, template string, statefulSet *appsv1.StatefulSet) bool {
+	prefix := template + "-" + statefulSet.Name + "-"
+	if !strings.HasPrefix(pvc.Name, prefix) {
+		return false
+	}
+	suffix := strings.TrimPrefix(pvc.Name, prefix)
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 || strconv.Itoa(ordinal) != suffix {
+		return false
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	return int32(ordinal) < replicas
+}
+
+// GetVolumeStatus reports the resize progress of a PVC towards the requested size
+func GetVolumeStatus(pvc *corev1.PersistentVolumeClaim, requested resource.Quantity) appsodyv1alpha1.VolumeStatus {
+	status := appsodyv1alpha1.VolumeStatus{
+		Name:          pvc.Name,
+		RequestedSize: requested.String(),
+		Phase:         appsodyv1alpha1.VolumeResizePhasePending,
+	}
+
+	capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+	if ok {
+		status.Capacity = capacity.String()
+		if capacity.Cmp(requested) >= 0 {
+			status.Phase = appsodyv1alpha1.VolumeResizePhaseResized
+			return status
+		}
+	}
+
+	for _, c := range pvc.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case corev1.PersistentVolumeClaimResizing:
+			status.Phase = appsodyv1alpha1.VolumeResizePhaseResizing
+		case corev1.PersistentVolumeClaimFileSystemResizePending:
+			status.Phase = appsodyv1alpha1.VolumeResizePhaseFileSystemResizePending
+		}
+	}
+	return status
+}
+
+// CustomizeServiceAccount ...
+func CustomizeServiceAccount(sa *corev1.ServiceAccount, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&sa.ObjectMeta, cr)
+	AddPullSecrets(sa, cr)
+}
+
+// AddPullSecrets adds the pull secrets of the application to the service account, keeping the
+// pull secrets added by others
+func AddPullSecrets(sa *corev1.ServiceAccount, cr *appsodyv1alpha1.AppsodyApplication) bool {
+	added := false
+	for _, secret := range GetPullSecrets(cr) {
+		found := false
+		for _, s := range sa.ImagePullSecrets {
+			if s.Name == secret.Name {
+				found = true
+			}
+		}
+		if !found {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, secret)
+			added = true
+		}
+	}
+	return added
+}
+
+// GetPullSecrets returns the image pull secrets of the application, starting with `pullSecret`
+func GetPullSecrets(cr *appsodyv1alpha1.AppsodyApplication) []corev1.LocalObjectReference {
+	var names []string
+	if cr.Spec.PullSecret != nil && *cr.Spec.PullSecret != "" {
+		names = append(names, *cr.Spec.PullSecret)
+	}
+	names = append(names, cr.Spec.PullSecrets...)
+
+	var secrets []corev1.LocalObjectReference
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			secrets = append(secrets, corev1.LocalObjectReference{Name: name})
+		}
+	}
+	return secrets
+}
+
+// DefaultImagePollInterval is the interval between two checks for a new digest of the image of an application
+const DefaultImagePollInterval = 5 * time.Minute
+
+// maxImageDigests bounds the digest history kept in the status of an application
+const maxImageDigests = 10
+
+// GetImage returns the image deployed for the application, which is pinned to the digest last resolved by the
+// image policy
+func GetImage(cr *appsodyv1alpha1.AppsodyApplication) string {
+	if cr.Spec.ImagePolicy != nil {
+		if last := getLastImageDigest(cr); last != nil && last.Image == cr.Spec.ApplicationImage {
+			return last.PinnedImage
+		}
+	}
+	return cr.Spec.ApplicationImage
+}
+
+func getLastImageDigest(cr *appsodyv1alpha1.AppsodyApplication) *appsodyv1alpha1.ImageDigestStatus {
+	if len(cr.Status.ImageDigests) == 0 {
+		return nil
+	}
+	return &cr.Status.ImageDigests[len(cr.Status.ImageDigests)-1]
+}
+
+// ValidateImagePolicy ...
+func ValidateImagePolicy(cr *appsodyv1alpha1.AppsodyApplication) error {
+	if cr.Spec.ImagePolicy == nil {
+		return nil
+	}
+	if _, err := GetImagePollInterval(cr); err != nil {
+		return err
+	}
+	if tag := cr.Spec.ImagePolicy.ImageStreamTag; tag != "" {
+		if parts := strings.Split(tag, ":"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("imageStreamTag %q must have the form <imagestream>:<tag>", tag)
+		}
+	}
+	return nil
+}
+
+// GetImagePollInterval ...
+func GetImagePollInterval(cr *appsodyv1alpha1.AppsodyApplication) (time.Duration, error) {
+	if cr.Spec.ImagePolicy == nil || cr.Spec.ImagePolicy.PollInterval == "" {
+		return DefaultImagePollInterval, nil
+	}
+	interval, err := time.ParseDuration(cr.Spec.ImagePolicy.PollInterval)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid pollInterval %q: %v", cr.Spec.ImagePolicy.PollInterval, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("pollInterval %q must be positive", cr.Spec.ImagePolicy.PollInterval)
+	}
+	return interval, nil
+}
+
+// GetImageStreamTagImage returns the image of the latest tag event of the ImageStream tag, and its digest
+func GetImageStreamTagImage(is *imagev1.ImageStream, tag string) (string, string, bool) {
+	for _, t := range is.Status.Tags {
+		if t.Tag == tag && len(t.Items) > 0 && t.Items[0].DockerImageReference != "" && t.Items[0].Image != "" {
+			return t.Items[0].DockerImageReference, t.Items[0].Image, true
+		}
+	}
+	return "", "", false
+}
+
+// RecordImageDigest adds the digest resolved for the image of the application to its history, unless it's
+// already the deployed one. Returns whether the deployed image changed.
+func RecordImageDigest(cr *appsodyv1alpha1.AppsodyApplication, pinnedImage string, digest string) bool {
+	if last := getLastImageDigest(cr); last != nil && last.Image == cr.Spec.ApplicationImage && last.Digest == digest {
+		return false
+	}
+	cr.Status.ImageDigests = append(cr.Status.ImageDigests, appsodyv1alpha1.ImageDigestStatus{
+		Image:       cr.Spec.ApplicationImage,
+		PinnedImage: pinnedImage,
+		Digest:      digest,
+		DeployedAt:  metav1.Now(),
+	})
+	if len(cr.Status.ImageDigests) > maxImageDigests {
+		cr.Status.ImageDigests = cr.Status.ImageDigests[len(cr.Status.ImageDigests)-maxImageDigests:]
+	}
+	return true
+}
+
+// CustomizeAffinity ...
+func CustomizeAffinity(a *corev1.Affinity, cr *appsodyv1alpha1.AppsodyApplication) {
+	a.NodeAffinity = nil
+	a.PodAntiAffinity = nil
+	if archs := GetArchitectures(cr); len(archs) > 0 {
+		customizeNodeAffinity(a, archs)
+	}
+	if cr.Spec.Scheduling != nil && cr.Spec.Scheduling.SpreadReplicas != "" {
+		a.PodAntiAffinity = newSpreadAntiAffinity(cr.Spec.Scheduling.SpreadReplicas, map[string]string{
+			"app.kubernetes.io/name": cr.Name,
+		})
+	}
+}
+
+// newSpreadAntiAffinity prefers scheduling pods matching the selector onto different zones or nodes
+func newSpreadAntiAffinity(mode appsodyv1alpha1.SpreadReplicasMode, selector map[string]string) *corev1.PodAntiAffinity {
+	topologyKey := "kubernetes.io/hostname"
+	if mode == appsodyv1alpha1.SpreadReplicasZone {
+		topologyKey = "failure-domain.beta.kubernetes.io/zone"
+	}
+	return &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+			{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: selector},
+					TopologyKey:   topologyKey,
+				},
+			},
+		},
+	}
+}
+
+func customizeNodeAffinity(a *corev1.Affinity, archs []string) {
+	a.NodeAffinity = &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   archs,
+							Key:      ArchitectureLabel,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i, arch := range archs {
+		term := corev1.PreferredSchedulingTerm{
+			Weight: int32(len(archs) - i),
+			Preference: corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{arch},
+						Key:      ArchitectureLabel,
+					},
+				},
+			},
+		}
+		a.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(a.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, term)
+	}
+}
+
+// ArchitectureLabel is the label holding the architecture of nodes
+const ArchitectureLabel = "kubernetes.io/arch"
+
+// GetArchitectures returns the architectures the application can be scheduled on, in order of preference. These are
+// the architectures of `architecture` supported by the image, or all the architectures of the image when
+// `architecture` is not set. The image is assumed to support any architecture until it's inspected.
+func GetArchitectures(cr *appsodyv1alpha1.AppsodyApplication) []string {
+	supported := cr.Status.ImageArchitectures
+	if len(supported) == 0 {
+		return cr.Spec.Architecture
+	}
+	if len(cr.Spec.Architecture) == 0 {
+		return supported
+	}
+	var archs []string
+	for _, arch := range cr.Spec.Architecture {
+		if containsString(supported, arch) {
+			archs = appendUnique(archs, arch)
+		}
+	}
+	return archs
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// CustomizeKnativeService ...
+func CustomizeKnativeService(ksvc *servingv1alpha1.Service, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&ksvc.ObjectMeta, cr)
+
+	if ksvc.Spec.Template == nil {
+		ksvc.Spec.Template = &servingv1alpha1.RevisionTemplateSpec{}
+	}
+	customizeKnativePodSpec(&ksvc.Spec.Template.Spec.PodSpec, cr)
+	ksvc.Spec.Template.Labels = mergeMaps(ksvc.Spec.Template.Labels, filterMetadata(cr.Labels))
+	ksvc.Spec.Template.Annotations = mergeMaps(ksvc.Spec.Template.Annotations, GetAnnotations(cr))
+	setPodAnnotation(&ksvc.Spec.Template.ObjectMeta, SeccompPodAnnotation, GetSeccompProfile(cr))
+	setPodAnnotation(&ksvc.Spec.Template.ObjectMeta, ConfigHashAnnotation, cr.Status.ConfigHash)
+
+	ksvc.Spec.Template.Name = GetKnativeRevisionName(cr)
+	CustomizeKnativeTraffic(ksvc, cr)
+}
+
+// customizeKnativePodSpec builds the pod spec of a Knative revision from the shared pod spec, adjusted
+// for what Knative allows: the container is named by Knative and probes must not set a port.
+func customizeKnativePodSpec(ps *corev1.PodSpec, cr *appsodyv1alpha1.AppsodyApplication) {
+	customizeAppPodSpec(ps, cr)
+	ps.Containers[0].Name = "user-container"
+
+	ps.Containers[0].LivenessProbe = knativeProbe(cr.Spec.LivenessProbe)
+	ps.Containers[0].ReadinessProbe = knativeProbe(cr.Spec.ReadinessProbe)
+
+	// Revision pods are only labelled by Knative, and Knative does not accept a priority class
+	if ps.Affinity != nil && ps.Affinity.PodAntiAffinity != nil {
+		ps.Affinity.PodAntiAffinity = newSpreadAntiAffinity(cr.Spec.Scheduling.SpreadReplicas, map[string]string{
+			"serving.knative.dev/service": cr.Name,
+		})
+	}
+	ps.PriorityClassName = ""
+
+	// Revisions are immutable, so only pin them to the architectures the user asked for and not to the ones read
+	// from the image, which are unknown until it's inspected and would roll out a new revision once it is
+	if ps.Affinity != nil && len(cr.Spec.Architecture) == 0 {
+		ps.Affinity.NodeAffinity = nil
+		if ps.Affinity.PodAntiAffinity == nil {
+			ps.Affinity = nil
+		}
+	}
+
+	// Knative rejects the scheduling fields of revisions unless their feature flag is enabled
+	if !IsKnativePodSpecFeatureEnabled("affinity") {
+		ps.Affinity = nil
+	}
+	if !IsKnativePodSpecFeatureEnabled("nodeselector") {
+		ps.NodeSelector = nil
+	}
+	if !IsKnativePodSpecFeatureEnabled("tolerations") {
+		ps.Tolerations = nil
+	}
+}
+
+// IsKnativePodSpecFeatureEnabled returns whether the Knative Serving pod spec feature is listed as enabled
+func IsKnativePodSpecFeatureEnabled(feature string) bool {
+	for _, f := range splitPrefixes(os.Getenv(KnativePodSpecFeaturesEnvVar)) {
+		if strings.ToLower(f) == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIgnoredKnativeFields returns the fields of the application that are not applied to its Knative revisions
+// because the feature flag of Knative Serving they require is not enabled
+func GetIgnoredKnativeFields(cr *appsodyv1alpha1.AppsodyApplication) []string {
+	ignored := []string{}
+	if !IsKnativePodSpecFeatureEnabled("affinity") {
+		if len(cr.Spec.Architecture) > 0 {
+			ignored = append(ignored, "architecture")
+		}
+		if cr.Spec.Scheduling != nil && cr.Spec.Scheduling.SpreadReplicas != "" {
+			ignored = append(ignored, "scheduling.spreadReplicas")
+		}
+	}
+	if cr.Spec.Scheduling == nil {
+		return ignored
+	}
+	if len(cr.Spec.Scheduling.NodeSelector) > 0 && !IsKnativePodSpecFeatureEnabled("nodeselector") {
+		ignored = append(ignored, "scheduling.nodeSelector")
+	}
+	if len(cr.Spec.Scheduling.Tolerations) > 0 && !IsKnativePodSpecFeatureEnabled("tolerations") {
+		ignored = append(ignored, "scheduling.tolerations")
+	}
+	return ignored
+}
+
+// knativeProbe returns a copy of the probe without the port, which Knative sets itself
+func knativeProbe(probe *corev1.Probe) *corev1.Probe {
+	if probe == nil {
+		return nil
+	}
+	probe = probe.DeepCopy()
+	if probe.HTTPGet != nil {
+		probe.HTTPGet.Port = intstr.IntOrString{}
+	}
+	if probe.TCPSocket != nil {
+		probe.TCPSocket.Port = intstr.IntOrString{}
+	}
+	return probe
+}
+
+// GetKnativeRevisionName returns the name of the revision generated from the current spec. The name
+// stays the same across reconciles as long as the revision template and its propagated metadata do not change.
+func GetKnativeRevisionName(cr *appsodyv1alpha1.AppsodyApplication) string {
+	if cr.Spec.Knative != nil && cr.Spec.Knative.RevisionSuffix != "" {
+		return cr.Name + "-" + cr.Spec.Knative.RevisionSuffix
+	}
+
+	revision := corev1.PodSpec{}
+	customizeKnativePodSpec(&revision, cr)
+	data, _ := json.Marshal(revision)
+	hash := fnv.New32a()
+	hash.Write(data)
+	for _, metadata := range []map[string]string{filterMetadata(cr.Labels), GetAnnotations(cr)} {
+		if len(metadata) > 0 {
+			data, _ = json.Marshal(metadata)
+			hash.Write(data)
+		}
+	}
+	hash.Write([]byte(GetSeccompProfile(cr)))
+	hash.Write([]byte(cr.Status.ConfigHash))
+	return fmt.Sprintf("%s-%08x", cr.Name, hash.Sum32())
+}
+
+// CustomizeKnativeTraffic ...
+func CustomizeKnativeTraffic(ksvc *servingv1alpha1.Service, cr *appsodyv1alpha1.AppsodyApplication) {
+	ksvc.Spec.Traffic = nil
+	if cr.Spec.Knative == nil {
+		return
+	}
+	for _, t := range cr.Spec.Knative.Traffic {
+		target := servingv1alpha1.TrafficTarget{
+			TrafficTarget: servingv1beta1.TrafficTarget{
+				Tag:            t.Tag,
+				RevisionName:   t.RevisionName,
+				LatestRevision: t.LatestRevision,
+				Percent:        t.Percent,
+			},
+		}
+		if target.RevisionName == "" && target.LatestRevision == nil {
+			latest := true
+			target.LatestRevision = &latest
+		}
+		ksvc.Spec.Traffic = append(ksvc.Spec.Traffic, target)
+	}
+}
+
+// ValidateKnativeTraffic ...
+func ValidateKnativeTraffic(cr *appsodyv1alpha1.AppsodyApplication) error {
+	if cr.Spec.Knative == nil || len(cr.Spec.Knative.Traffic) == 0 {
+		return nil
+	}
+
+	total := 0
+	tags := map[string]bool{}
+	for i, t := range cr.Spec.Knative.Traffic {
+		latest := t.LatestRevision != nil && *t.LatestRevision
+		if t.RevisionName != "" && latest {
+			return fmt.Errorf("traffic target %d sets both `revisionName` and `latestRevision`", i)
+		}
+		if t.RevisionName != "" && !strings.HasPrefix(t.RevisionName, cr.Name+"-") {
+			return fmt.Errorf("traffic target %d references revision `%s` that does not belong to `%s`", i, t.RevisionName, cr.Name)
+		}
+		if t.Tag != "" {
+			if tags[t.Tag] {
+				return fmt.Errorf("traffic tag `%s` is used more than once", t.Tag)
+			}
+			tags[t.Tag] = true
+		}
+		total += t.Percent
 	}
-	return labels
+	if total != 100 {
+		return fmt.Errorf("traffic percentages add up to %d instead of 100", total)
+	}
+	return nil
 }
 
-// CustomizeRoute ...
-func CustomizeRoute(route *routev1.Route, cr *appsodyv1alpha1.AppsodyApplication) {
-	route.Labels = GetLabels(cr)
-	route.Spec.To.Kind = "Service"
-	route.Spec.To.Name = cr.Name
-	weight := int32(100)
-	route.Spec.To.Weight = &weight
-	if route.Spec.Port == nil {
-		route.Spec.Port = &routev1.RoutePort{}
+// UpdateKnativeStatus copies the URLs and traffic assignment reported by Knative into the status of the CR
+func UpdateKnativeStatus(cr *appsodyv1alpha1.AppsodyApplication, ksvc *servingv1alpha1.Service) {
+	status := &appsodyv1alpha1.KnativeStatus{
+		LatestCreatedRevisionName: ksvc.Status.LatestCreatedRevisionName,
+		LatestReadyRevisionName:   ksvc.Status.LatestReadyRevisionName,
 	}
-	route.Spec.Port.TargetPort = intstr.FromInt(int(cr.Spec.Service.Port))
+	if ksvc.Status.URL != nil {
+		status.URL = ksvc.Status.URL.String()
+	}
+	for _, t := range ksvc.Status.Traffic {
+		ts := appsodyv1alpha1.KnativeTrafficStatus{
+			Tag:          t.Tag,
+			RevisionName: t.RevisionName,
+			Percent:      t.Percent,
+		}
+		if t.URL != nil {
+			ts.URL = t.URL.String()
+		}
+		status.Traffic = append(status.Traffic, ts)
+	}
+	cr.Status.Knative = status
 }
 
-// ErrorIsNoMatchesForKind ...
-func ErrorIsNoMatchesForKind(err error, kind string, version string) bool {
-	return strings.HasPrefix(err.Error(), fmt.Sprintf("no matches for kind \"%s\" in version \"%s\"", kind, version))
+// NewKnativeService returns an unstructured Knative Service in the given group version
+func NewKnativeService(groupVersion string, meta metav1.ObjectMeta) *unstructured.Unstructured {
+	ksvc := &unstructured.Unstructured{}
+	ksvc.SetAPIVersion(groupVersion)
+	ksvc.SetKind("Service")
+	ksvc.SetName(meta.Name)
+	ksvc.SetNamespace(meta.Namespace)
+	return ksvc
 }
 
-// CustomizeService ...
-func CustomizeService(svc *corev1.Service, cr *appsodyv1alpha1.AppsodyApplication) {
-	svc.Labels = GetLabels(cr)
-	if len(svc.Spec.Ports) == 0 {
-		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{})
+// NewKnativeRevision returns an unstructured Knative Revision in the given group version
+func NewKnativeRevision(groupVersion string, meta metav1.ObjectMeta) *unstructured.Unstructured {
+	rev := &unstructured.Unstructured{}
+	rev.SetAPIVersion(groupVersion)
+	rev.SetKind("Revision")
+	rev.SetName(meta.Name)
+	rev.SetNamespace(meta.Namespace)
+	return rev
+}
+
+// GetKnativeRevisionConfigMaps returns the names of the ConfigMaps the pods of the Knative Revision refer to, through
+// their volumes or their environment variables
+func GetKnativeRevisionConfigMaps(rev *unstructured.Unstructured) ([]string, error) {
+	typed := &servingv1alpha1.Revision{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rev.Object, typed); err != nil {
+		return nil, err
 	}
-	svc.Spec.Ports[0].Port = cr.Spec.Service.Port
-	svc.Spec.Ports[0].TargetPort = intstr.FromInt(int(cr.Spec.Service.Port))
-	svc.Spec.Type = *cr.Spec.Service.Type
-	svc.Spec.Selector = map[string]string{
-		"app.kubernetes.io/name": cr.Name,
+	names := []string{}
+	for _, v := range typed.Spec.Volumes {
+		if v.ConfigMap != nil {
+			names = append(names, v.ConfigMap.Name)
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.ConfigMap != nil {
+					names = append(names, source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+	for _, c := range typed.Spec.Containers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				names = append(names, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
 	}
+	return names, nil
 }
 
-// CustomizePodSpec ...
-func CustomizePodSpec(pts *corev1.PodTemplateSpec, cr *appsodyv1alpha1.AppsodyApplication) {
-	pts.Labels = GetLabels(cr)
-	if len(pts.Spec.Containers) == 0 {
-		pts.Spec.Containers = append(pts.Spec.Containers, corev1.Container{})
-	}
-	pts.Spec.Containers[0].Name = "app"
-	if len(pts.Spec.Containers[0].Ports) == 0 {
-		pts.Spec.Containers[0].Ports = append(pts.Spec.Containers[0].Ports, corev1.ContainerPort{})
-	}
-	pts.Spec.Containers[0].Ports[0].ContainerPort = cr.Spec.Service.Port
-	pts.Spec.Containers[0].Image = cr.Spec.ApplicationImage
-	pts.Spec.Containers[0].Resources = *cr.Spec.ResourceConstraints
-	pts.Spec.Containers[0].ReadinessProbe = cr.Spec.ReadinessProbe
-	pts.Spec.Containers[0].LivenessProbe = cr.Spec.LivenessProbe
-	pts.Spec.Containers[0].VolumeMounts = cr.Spec.VolumeMounts
-	pts.Spec.Containers[0].ImagePullPolicy = *cr.Spec.PullPolicy
-	pts.Spec.Containers[0].Env = cr.Spec.Env
-	pts.Spec.Containers[0].EnvFrom = cr.Spec.EnvFrom
-	pts.Spec.Volumes = cr.Spec.Volumes
+// knativeServiceFields are the fields of a Knative Service set by the operator. The deprecated v1alpha1 modes
+// are listed so that they are removed once migrated into the template and traffic fields.
+var knativeServiceFields = [][]string{
+	{"metadata", "labels"},
+	{"metadata", "annotations"},
+	{"spec", "runLatest"},
+	{"spec", "release"},
+	{"spec", "pinned"},
+	{"spec", "manual"},
+	{"spec", "traffic"},
+	{"spec", "template", "metadata", "name"},
+	{"spec", "template", "metadata", "labels"},
+	{"spec", "template", "metadata", "annotations"},
+	{"spec", "template", "spec", "volumes"},
+	{"spec", "template", "spec", "imagePullSecrets"},
+	{"spec", "template", "spec", "serviceAccountName"},
+	{"spec", "template", "spec", "affinity"},
+	{"spec", "template", "spec", "nodeSelector"},
+	{"spec", "template", "spec", "tolerations"},
+	{"spec", "template", "spec", "priorityClassName"},
+	{"spec", "template", "spec", "securityContext"},
+}
 
-	if cr.Spec.ServiceAccountName != nil && *cr.Spec.ServiceAccountName != "" {
-		pts.Spec.ServiceAccountName = *cr.Spec.ServiceAccountName
-	} else {
-		pts.Spec.ServiceAccountName = cr.Name
+// knativeContainerFields are the fields of the container of a Knative Service set by the operator
+var knativeContainerFields = []string{"name", "image", "ports", "resources", "readinessProbe", "livenessProbe",
+	"volumeMounts", "imagePullPolicy", "env", "envFrom", "securityContext"}
+
+// CustomizeUnstructuredKnativeService customizes a Knative Service of any supported version. The v1beta1
+// and v1 schemas match the template-based v1alpha1 fields that CustomizeKnativeService sets, so the desired
+// fields are computed as v1alpha1 and only the fields the operator sets are written back to the object. Fields
+// v1alpha1 doesn't model, or set by other controllers, are kept.
+func CustomizeUnstructuredKnativeService(ksvc *unstructured.Unstructured, cr *appsodyv1alpha1.AppsodyApplication) error {
+	typed, err := ToKnativeService(ksvc)
+	if err != nil {
+		return err
 	}
-	pts.Spec.RestartPolicy = corev1.RestartPolicyAlways
-	pts.Spec.DNSPolicy = corev1.DNSClusterFirst
 
-	if len(cr.Spec.Architecture) > 0 {
-		pts.Spec.Affinity = &corev1.Affinity{}
-		CustomizeAffinity(pts.Spec.Affinity, cr)
+	migrated, err := MigrateKnativeService(typed)
+	if err != nil {
+		return err
 	}
-}
+	traffic := typed.Spec.Traffic
 
-// CustomizePersistence ...
-func CustomizePersistence(statefulSet *appsv1.StatefulSet, cr *appsodyv1alpha1.AppsodyApplication) {
-	if len(statefulSet.Spec.VolumeClaimTemplates) == 0 {
-		var pvc *corev1.PersistentVolumeClaim
-		if cr.Spec.Storage.VolumeClaimTemplate != nil {
-			pvc = cr.Spec.Storage.VolumeClaimTemplate
-		} else {
-			pvc = &corev1.PersistentVolumeClaim{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "pvc",
-					Namespace: cr.Namespace,
-					Labels:    GetLabels(cr),
-				},
-				Spec: corev1.PersistentVolumeClaimSpec{
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceStorage: resource.MustParse(cr.Spec.Storage.Size),
-						},
-					},
-					AccessModes: []corev1.PersistentVolumeAccessMode{
-						corev1.ReadWriteOnce,
-					},
-				},
-			}
+	CustomizeKnativeService(typed, cr)
+
+	// Keep the traffic split of a migrated Service until the user takes it over
+	if migrated && (cr.Spec.Knative == nil || len(cr.Spec.Knative.Traffic) == 0) {
+		typed.Spec.Traffic = traffic
+	}
 
+	desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return err
+	}
+	for _, field := range knativeServiceFields {
+		if err = copyNestedField(ksvc.Object, desired, field...); err != nil {
+			return err
 		}
-		statefulSet.Spec.VolumeClaimTemplates = append(statefulSet.Spec.VolumeClaimTemplates, *pvc)
 	}
 
-	found := false
-	for _, v := range statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts {
-		if v.Name == statefulSet.Spec.VolumeClaimTemplates[0].Name {
-			found = true
+	containers, _, err := unstructured.NestedSlice(desired, "spec", "template", "spec", "containers")
+	if err != nil || len(containers) == 0 {
+		return err
+	}
+	existing, _, err := unstructured.NestedSlice(ksvc.Object, "spec", "template", "spec", "containers")
+	if err != nil {
+		return err
+	}
+	container, ok := map[string]interface{}{}, false
+	if len(existing) > 0 {
+		container, ok = existing[0].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("The container of Knative Service %s is not an object", ksvc.GetName())
 		}
+	} else {
+		existing = []interface{}{container}
 	}
-	if !found {
-		vm := corev1.VolumeMount{
-			Name:      statefulSet.Spec.VolumeClaimTemplates[0].Name,
-			MountPath: cr.Spec.Storage.MountPath,
+	for _, field := range knativeContainerFields {
+		if err = copyNestedField(container, containers[0].(map[string]interface{}), field); err != nil {
+			return err
 		}
-		statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts = append(statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts, vm)
 	}
-
+	return unstructured.SetNestedSlice(ksvc.Object, existing, "spec", "template", "spec", "containers")
 }
 
-// CustomizeServiceAccount ...
-func CustomizeServiceAccount(sa *corev1.ServiceAccount, cr *appsodyv1alpha1.AppsodyApplication) {
-	sa.Labels = GetLabels(cr)
-	if cr.Spec.PullSecret != nil {
-		if len(sa.ImagePullSecrets) == 0 {
-			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{
-				Name: *cr.Spec.PullSecret,
-			})
-		} else {
-			sa.ImagePullSecrets[0].Name = *cr.Spec.PullSecret
-		}
+// copyNestedField sets the field of obj to its value in src, or removes it when src doesn't set it
+func copyNestedField(obj map[string]interface{}, src map[string]interface{}, fields ...string) error {
+	value, found, err := unstructured.NestedFieldNoCopy(src, fields...)
+	if err != nil {
+		return err
 	}
+	if !found || value == nil {
+		unstructured.RemoveNestedField(obj, fields...)
+		return nil
+	}
+	return unstructured.SetNestedField(obj, value, fields...)
 }
 
-// CustomizeAffinity ...
-func CustomizeAffinity(a *corev1.Affinity, cr *appsodyv1alpha1.AppsodyApplication) {
+// ToKnativeService converts an unstructured Knative Service into the typed v1alpha1 representation
+func ToKnativeService(ksvc *unstructured.Unstructured) (*servingv1alpha1.Service, error) {
+	typed := &servingv1alpha1.Service{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(ksvc.Object, typed)
+	return typed, err
+}
 
-	a.NodeAffinity = &corev1.NodeAffinity{
-		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-			NodeSelectorTerms: []corev1.NodeSelectorTerm{
-				{
-					MatchExpressions: []corev1.NodeSelectorRequirement{
-						{
-							Operator: corev1.NodeSelectorOpIn,
-							Values:   cr.Spec.Architecture,
-							Key:      "beta.kubernetes.io/arch",
-						},
-					},
-				},
-			},
-		},
+// MigrateKnativeService rewrites the deprecated v1alpha1 `runLatest`, `release` and `pinned` modes into the
+// template and traffic fields shared with v1beta1 and v1. Returns true if the Service had to be migrated.
+func MigrateKnativeService(ksvc *servingv1alpha1.Service) (bool, error) {
+	if ksvc.Spec.DeprecatedRunLatest == nil && ksvc.Spec.DeprecatedRelease == nil &&
+		ksvc.Spec.DeprecatedPinned == nil && ksvc.Spec.DeprecatedManual == nil {
+		return false, nil
 	}
 
-	archs := len(cr.Spec.Architecture)
-	for i := range cr.Spec.Architecture {
-		arch := cr.Spec.Architecture[i]
-		term := corev1.PreferredSchedulingTerm{
-			Weight: int32(archs - i),
-			Preference: corev1.NodeSelectorTerm{
-				MatchExpressions: []corev1.NodeSelectorRequirement{
-					{
-						Operator: corev1.NodeSelectorOpIn,
-						Values:   []string{arch},
-						Key:      "beta.kubernetes.io/arch",
-					},
-				},
-			},
-		}
-		a.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(a.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, term)
+	ctx := context.TODO()
+	current := &servingv1beta1.Service{}
+	if err := ksvc.ConvertUp(ctx, current); err != nil {
+		return false, err
+	}
+
+	migrated := &servingv1alpha1.Service{}
+	if err := migrated.ConvertDown(ctx, current); err != nil {
+		return false, err
 	}
+	ksvc.Spec = migrated.Spec
+	return true, nil
 }
 
-// CustomizeKnativeService ...
-func CustomizeKnativeService(ksvc *servingv1alpha1.Service, cr *appsodyv1alpha1.AppsodyApplication) {
-	ksvc.Labels = GetLabels(cr)
+// HookOfLabel labels the hook Jobs of an application with its name
+const HookOfLabel = "appsody.dev/hook-of"
 
-	if ksvc.Spec.Template == nil {
-		ksvc.Spec.Template = &servingv1alpha1.RevisionTemplateSpec{}
+// HookLabel labels hook Jobs with the name of the hook
+const HookLabel = "appsody.dev/hook"
+
+// GetHook ...
+func GetHook(cr *appsodyv1alpha1.AppsodyApplication, name appsodyv1alpha1.HookName) *appsodyv1alpha1.AppsodyApplicationHook {
+	if cr.Spec.Hooks == nil {
+		return nil
 	}
-	if len(ksvc.Spec.Template.Spec.Containers) == 0 {
-		ksvc.Spec.Template.Spec.Containers = append(ksvc.Spec.Template.Spec.Containers, corev1.Container{Name: "user-container"})
+	if name == appsodyv1alpha1.HookNamePreDeploy {
+		return cr.Spec.Hooks.PreDeploy
 	}
+	return cr.Spec.Hooks.PostDeploy
+}
 
-	if len(ksvc.Spec.Template.Spec.Containers[0].Ports) == 0 {
-		ksvc.Spec.Template.Spec.Containers[0].Ports = append(ksvc.Spec.Template.Spec.Containers[0].Ports, corev1.ContainerPort{})
+// GetHookJobName returns the name of the Job running the hook for the current pod template. A new Job
+// is run whenever the hook or the pod template of the application changes.
+func GetHookJobName(cr *appsodyv1alpha1.AppsodyApplication, name appsodyv1alpha1.HookName) string {
+	job := &batchv1.Job{}
+	CustomizeHookJob(job, cr, name)
+	data, _ := json.Marshal(job.Spec)
+	hash := fnv.New32a()
+	hash.Write(data)
+
+	kind := "pre-deploy"
+	if name == appsodyv1alpha1.HookNamePostDeploy {
+		kind = "post-deploy"
 	}
-	ksvc.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = cr.Spec.Service.Port
-	ksvc.Spec.Template.Spec.Containers[0].Name = "user-container"
-	ksvc.Spec.Template.Spec.Containers[0].Image = cr.Spec.ApplicationImage
-	// Knative sets its own resource constraints
-	//ksvc.Spec.Template.Spec.Containers[0].Resources = *cr.Spec.ResourceConstraints
-	ksvc.Spec.Template.Spec.Containers[0].ReadinessProbe = cr.Spec.ReadinessProbe
-	ksvc.Spec.Template.Spec.Containers[0].LivenessProbe = cr.Spec.LivenessProbe
-	ksvc.Spec.Template.Spec.Containers[0].VolumeMounts = cr.Spec.VolumeMounts
-	ksvc.Spec.Template.Spec.Containers[0].ImagePullPolicy = *cr.Spec.PullPolicy
-	ksvc.Spec.Template.Spec.Containers[0].Env = cr.Spec.Env
-	ksvc.Spec.Template.Spec.Containers[0].EnvFrom = cr.Spec.EnvFrom
+	return fmt.Sprintf("%s-%s-%08x", cr.Name, kind, hash.Sum32())
+}
 
-	ksvc.Spec.Template.Spec.Volumes = cr.Spec.Volumes
+// CustomizeHookJob builds the Job of a hook from the pod template of the application. Only used to create
+// the Job, as its template is immutable.
+func CustomizeHookJob(job *batchv1.Job, cr *appsodyv1alpha1.AppsodyApplication, name appsodyv1alpha1.HookName) {
+	hook := GetHook(cr, name)
+	hookLabels := map[string]string{HookOfLabel: cr.Name, HookLabel: string(name)}
+
+	CustomizeObjectMeta(&job.ObjectMeta, cr)
+	job.Labels = mergeMaps(job.Labels, hookLabels)
+	job.Spec.BackoffLimit = hook.BackoffLimit
+	job.Spec.ActiveDeadlineSeconds = hook.ActiveDeadlineSeconds
+
+	pts := &job.Spec.Template
+	CustomizePodSpec(pts, cr)
+	// Hook pods must not be selected by the Service of the application
+	pts.Labels = mergeMaps(filterMetadata(cr.Labels), hookLabels)
+	delete(pts.Labels, "app.kubernetes.io/name")
+	pts.Labels["app.kubernetes.io/managed-by"] = "appsody-operator"
+	pts.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	container := &pts.Spec.Containers[0]
+	container.Name = "hook"
+	container.Command = hook.Command
+	container.Args = hook.Args
+	container.Env = append(append([]corev1.EnvVar{}, container.Env...), hook.Env...)
+	container.Ports = nil
+	container.ReadinessProbe = nil
+	container.LivenessProbe = nil
+}
 
-	if cr.Spec.ServiceAccountName != nil && *cr.Spec.ServiceAccountName != "" {
-		ksvc.Spec.Template.Spec.ServiceAccountName = *cr.Spec.ServiceAccountName
-	} else {
-		ksvc.Spec.Template.Spec.ServiceAccountName = cr.Name
+// GetHookStatus ...
+func GetHookStatus(job *batchv1.Job, name appsodyv1alpha1.HookName) appsodyv1alpha1.HookStatus {
+	status := appsodyv1alpha1.HookStatus{
+		Name:           name,
+		JobName:        job.Name,
+		Phase:          appsodyv1alpha1.HookPhaseRunning,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+		FailedAttempts: job.Status.Failed,
+		BackoffLimit:   job.Spec.BackoffLimit,
+		LogsSelector:   "job-name=" + job.Name,
 	}
-
-	if ksvc.Spec.Template.Spec.Containers[0].LivenessProbe != nil {
-		if ksvc.Spec.Template.Spec.Containers[0].LivenessProbe.HTTPGet != nil {
-			ksvc.Spec.Template.Spec.Containers[0].LivenessProbe.HTTPGet.Port = intstr.IntOrString{}
-		}
-		if ksvc.Spec.Template.Spec.Containers[0].LivenessProbe.TCPSocket != nil {
-			ksvc.Spec.Template.Spec.Containers[0].LivenessProbe.TCPSocket.Port = intstr.IntOrString{}
+	if job.Status.Succeeded > 0 {
+		status.Phase = appsodyv1alpha1.HookPhaseSucceeded
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			status.Phase = appsodyv1alpha1.HookPhaseFailed
+			status.Message = c.Message
 		}
 	}
+	return status
+}
 
-	if ksvc.Spec.Template.Spec.Containers[0].ReadinessProbe != nil {
-		if ksvc.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet != nil {
-			ksvc.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet.Port = intstr.IntOrString{}
+// SetHookStatus ...
+func SetHookStatus(hookStatus appsodyv1alpha1.HookStatus, status *appsodyv1alpha1.AppsodyApplicationStatus) {
+	for i := range status.Hooks {
+		if status.Hooks[i].Name == hookStatus.Name {
+			status.Hooks[i] = hookStatus
+			return
 		}
-		if ksvc.Spec.Template.Spec.Containers[0].ReadinessProbe.TCPSocket != nil {
-			ksvc.Spec.Template.Spec.Containers[0].ReadinessProbe.TCPSocket.Port = intstr.IntOrString{}
+	}
+	status.Hooks = append(status.Hooks, hookStatus)
+}
+
+// RemoveHookStatus ...
+func RemoveHookStatus(name appsodyv1alpha1.HookName, status *appsodyv1alpha1.AppsodyApplicationStatus) {
+	for i := range status.Hooks {
+		if status.Hooks[i].Name == name {
+			status.Hooks = append(status.Hooks[:i], status.Hooks[i+1:]...)
+			return
 		}
 	}
+}
+
+// IsDeploymentRolledOut ...
+func IsDeploymentRolledOut(deploy *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	return deploy.Status.ObservedGeneration >= deploy.Generation &&
+		deploy.Status.UpdatedReplicas == replicas && deploy.Status.AvailableReplicas == replicas
+}
 
+// IsStatefulSetRolledOut ...
+func IsStatefulSetRolledOut(statefulSet *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+		statefulSet.Status.UpdatedReplicas == replicas && statefulSet.Status.ReadyReplicas == replicas
 }
 
 // CustomizeHPA ...
 func CustomizeHPA(hpa *autoscalingv1.HorizontalPodAutoscaler, cr *appsodyv1alpha1.AppsodyApplication) {
-	hpa.Labels = GetLabels(cr)
+	CustomizeObjectMeta(&hpa.ObjectMeta, cr)
 
 	hpa.Spec.MaxReplicas = cr.Spec.Autoscaling.MaxReplicas
 	hpa.Spec.MinReplicas = cr.Spec.Autoscaling.MinReplicas
@@ -253,15 +1516,65 @@ func CustomizeHPA(hpa *autoscalingv1.HorizontalPodAutoscaler, cr *appsodyv1alpha
 	hpa.Spec.ScaleTargetRef.Name = cr.Name
 	hpa.Spec.ScaleTargetRef.APIVersion = "apps/v1"
 
-	if cr.Spec.Storage != nil {
-		hpa.Spec.ScaleTargetRef.Kind = "StatefulSet"
-	} else {
-		hpa.Spec.ScaleTargetRef.Kind = "Deployment"
+	hpa.Spec.ScaleTargetRef.Kind = string(GetWorkloadKind(cr))
+}
+
+// GetWorkloadKind returns the kind of the workload running the application, inferring it from
+// createKnativeService and storage when workloadKind is not set
+func GetWorkloadKind(cr *appsodyv1alpha1.AppsodyApplication) appsodyv1alpha1.WorkloadKind {
+	switch {
+	case cr.Spec.WorkloadKind != "":
+		return cr.Spec.WorkloadKind
+	case cr.Spec.CreateKnativeService != nil && *cr.Spec.CreateKnativeService:
+		return appsodyv1alpha1.WorkloadKindKnativeService
+	case cr.Spec.Storage != nil:
+		return appsodyv1alpha1.WorkloadKindStatefulSet
+	default:
+		return appsodyv1alpha1.WorkloadKindDeployment
+	}
+}
+
+// ValidateWorkloadKind checks that the fields of the application are supported by its workload kind
+func ValidateWorkloadKind(cr *appsodyv1alpha1.AppsodyApplication) error {
+	kind := GetWorkloadKind(cr)
+	if kind == appsodyv1alpha1.WorkloadKindCronJob && cr.Spec.Schedule == "" {
+		return fmt.Errorf("A schedule is required when workloadKind is %v", kind)
+	}
+	if kind != appsodyv1alpha1.WorkloadKindCronJob && cr.Spec.Schedule != "" {
+		return fmt.Errorf("A schedule is only supported when workloadKind is %v", appsodyv1alpha1.WorkloadKindCronJob)
+	}
+	if cr.Spec.Storage != nil && kind != appsodyv1alpha1.WorkloadKindStatefulSet {
+		return fmt.Errorf("Storage is only supported when workloadKind is %v", appsodyv1alpha1.WorkloadKindStatefulSet)
+	}
+	if cr.Spec.Autoscaling != nil && kind != appsodyv1alpha1.WorkloadKindDeployment && kind != appsodyv1alpha1.WorkloadKindStatefulSet {
+		return fmt.Errorf("Autoscaling is not supported when workloadKind is %v", kind)
 	}
+	return nil
+}
+
+// CustomizeCronJob ...
+func CustomizeCronJob(cronJob *batchv1beta1.CronJob, cr *appsodyv1alpha1.AppsodyApplication) {
+	CustomizeObjectMeta(&cronJob.ObjectMeta, cr)
+	cronJob.Spec.Schedule = cr.Spec.Schedule
+
+	pts := &cronJob.Spec.JobTemplate.Spec.Template
+	CustomizePodSpec(pts, cr)
+	// Batch workers neither receive traffic nor run until they are killed
+	pts.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+	pts.Spec.Containers[0].ReadinessProbe = nil
+	pts.Spec.Containers[0].LivenessProbe = nil
+}
+
+// IsDaemonSetRolledOut ...
+func IsDaemonSetRolledOut(daemonSet *appsv1.DaemonSet) bool {
+	return daemonSet.Status.ObservedGeneration >= daemonSet.Generation &&
+		daemonSet.Status.UpdatedNumberScheduled == daemonSet.Status.DesiredNumberScheduled &&
+		daemonSet.Status.NumberAvailable == daemonSet.Status.DesiredNumberScheduled
 }
 
-// InitAndValidate ...
-func InitAndValidate(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1alpha1.AppsodyApplicationSpec, constants *appsodyv1alpha1.AppsodyApplicationSpec) {
+// InitAndValidate applies the defaults and the constants of the stack to the application and returns the
+// fields which differ from the constants
+func InitAndValidate(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1alpha1.AppsodyApplicationSpec, constants *StackConstants) []appsodyv1alpha1.PolicyViolation {
 
 	if cr.Spec.PullPolicy == nil {
 		cr.Spec.PullPolicy = defaults.PullPolicy
@@ -271,20 +1584,29 @@ func InitAndValidate(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1a
 		}
 	}
 
+	if cr.Spec.Replicas == nil {
+		cr.Spec.Replicas = defaults.Replicas
+	}
+
 	if cr.Spec.PullSecret == nil {
 		cr.Spec.PullSecret = defaults.PullSecret
 	}
 
+	if cr.Spec.PullSecrets == nil {
+		cr.Spec.PullSecrets = defaults.PullSecrets
+	}
+
 	if cr.Spec.ServiceAccountName == nil {
 		cr.Spec.ServiceAccountName = defaults.ServiceAccountName
 	}
 
-	if cr.Spec.ReadinessProbe == nil {
-		cr.Spec.ReadinessProbe = defaults.ReadinessProbe
-	}
-	if cr.Spec.LivenessProbe == nil {
-		cr.Spec.LivenessProbe = defaults.LivenessProbe
+	if cr.Spec.PatchServiceAccount == nil {
+		cr.Spec.PatchServiceAccount = defaults.PatchServiceAccount
 	}
+
+	cr.Spec.ReadinessProbe = mergeProbe(defaults.ReadinessProbe, cr.Spec.ReadinessProbe)
+	cr.Spec.LivenessProbe = mergeProbe(defaults.LivenessProbe, cr.Spec.LivenessProbe)
+
 	if cr.Spec.Env == nil {
 		cr.Spec.Env = defaults.Env
 	}
@@ -306,10 +1628,28 @@ func InitAndValidate(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1a
 		} else {
 			cr.Spec.ResourceConstraints = &corev1.ResourceRequirements{}
 		}
+	} else if defaults.ResourceConstraints != nil {
+		resources := &corev1.ResourceRequirements{}
+		if mergeDefaults(defaults.ResourceConstraints, cr.Spec.ResourceConstraints, resources, false) {
+			cr.Spec.ResourceConstraints = resources
+		}
 	}
 
 	if cr.Spec.Autoscaling == nil {
 		cr.Spec.Autoscaling = defaults.Autoscaling
+	} else if defaults.Autoscaling != nil {
+		autoscaling := &appsodyv1alpha1.AppsodyApplicationAutoScaling{}
+		if mergeDefaults(defaults.Autoscaling, cr.Spec.Autoscaling, autoscaling, false) {
+			cr.Spec.Autoscaling = autoscaling
+		}
+	}
+
+	if cr.Spec.Scheduling == nil {
+		cr.Spec.Scheduling = defaults.Scheduling
+	}
+
+	if cr.Spec.SecurityContext == nil {
+		cr.Spec.SecurityContext = defaults.SecurityContext
 	}
 
 	if cr.Spec.Expose == nil {
@@ -320,93 +1660,297 @@ func InitAndValidate(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1a
 		cr.Spec.CreateKnativeService = defaults.CreateKnativeService
 	}
 
-	if cr.Spec.Service == nil {
-		cr.Spec.Service = defaults.Service
+	service := &appsodyv1alpha1.AppsodyApplicationService{}
+	if cr.Spec.Service != nil && defaults.Service != nil {
+		if !mergeDefaults(defaults.Service, cr.Spec.Service, service, false) {
+			service = cr.Spec.Service.DeepCopy()
+		}
+	} else if cr.Spec.Service != nil {
+		service = cr.Spec.Service.DeepCopy()
+	} else if defaults.Service != nil {
+		service = defaults.Service.DeepCopy()
+	}
+	if service.Type == nil {
+		st := corev1.ServiceTypeClusterIP
+		service.Type = &st
+	}
+	if service.Port == 0 {
+		service.Port = 8080
+	}
+	cr.Spec.Service = service
+
+	if constants != nil {
+		return applyConstants(cr, constants)
+	}
+	return nil
+}
+
+// ProfileLabel is the label of namespaces selecting the profile of the applications they contain
+const ProfileLabel = "appsody.dev/profile"
+
+// ApplyProfile layers the overrides of a profile onto the defaults of a stack. Unlike the fields of applications,
+// the fields a profile sets to a zero value, such as `expose: false` or `replicas: 0`, override the defaults.
+func ApplyProfile(defaults appsodyv1alpha1.AppsodyApplicationSpec, profile appsodyv1alpha1.AppsodyApplicationSpec) appsodyv1alpha1.AppsodyApplicationSpec {
+	merged := appsodyv1alpha1.AppsodyApplicationSpec{}
+	if !mergeDefaults(defaults, profile, &merged, true) {
+		return defaults
+	}
+	// The image is the only required field, so it is serialized even when the profile doesn't set it
+	if profile.ApplicationImage == "" {
+		merged.ApplicationImage = defaults.ApplicationImage
+	}
+	merged.ReadinessProbe = mergeProbe(defaults.ReadinessProbe, profile.ReadinessProbe)
+	merged.LivenessProbe = mergeProbe(defaults.LivenessProbe, profile.LivenessProbe)
+	merged.Profile = ""
+	return merged
+}
+
+// mergeDefaults sets merged to the defaults of the stack overridden by the value of the application, following
+// JSON merge patch semantics: objects are merged recursively, while any other value, including arrays, replaces
+// the default. Zero values of the application are considered unset, as most fields omit them anyway, unless
+// zeroValues is set. It returns false if the values can't be merged.
+func mergeDefaults(defaults, value, merged interface{}, zeroValues bool) bool {
+	var d, v map[string]interface{}
+	if b, err := json.Marshal(defaults); err != nil || json.Unmarshal(b, &d) != nil {
+		return false
+	}
+	if b, err := json.Marshal(value); err != nil || json.Unmarshal(b, &v) != nil {
+		return false
 	}
+	b, err := json.Marshal(mergeObjects(d, v, zeroValues))
+	return err == nil && json.Unmarshal(b, merged) == nil
+}
 
-	if cr.Spec.Service.Type == nil {
-		if defaults.Service.Type != nil {
-			cr.Spec.Service.Type = defaults.Service.Type
+// mergeObjects returns the JSON objects merged recursively, with the values of value overriding the ones of defaults.
+// Zero values of value are skipped unless zeroValues is set.
+func mergeObjects(defaults, value map[string]interface{}, zeroValues bool) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range value {
+		switch v {
+		case nil, "", false, float64(0):
+			if !zeroValues {
+				continue
+			}
+		}
+		vm, ok := v.(map[string]interface{})
+		dm, ok2 := merged[k].(map[string]interface{})
+		if ok && ok2 {
+			merged[k] = mergeObjects(dm, vm, zeroValues)
 		} else {
-			st := corev1.ServiceTypeClusterIP
-			cr.Spec.Service.Type = &st
+			merged[k] = v
 		}
 	}
-	if cr.Spec.Service.Port == 0 {
-		if defaults.Service.Port != 0 {
-			cr.Spec.Service.Port = defaults.Service.Port
-		} else {
-			cr.Spec.Service.Port = 8080
+	return merged
+}
+
+// mergeProbe merges the probe of the application into the default probe of the stack. A probe only has one
+// handler, so the default handler is dropped when the application sets one of another kind.
+func mergeProbe(defaults, value *corev1.Probe) *corev1.Probe {
+	if defaults == nil || value == nil {
+		if value == nil {
+			return defaults
 		}
+		return value
+	}
+	if (value.Exec != nil && defaults.Exec == nil) || (value.HTTPGet != nil && defaults.HTTPGet == nil) ||
+		(value.TCPSocket != nil && defaults.TCPSocket == nil) {
+		defaults = defaults.DeepCopy()
+		defaults.Handler = corev1.Handler{}
 	}
+	merged := &corev1.Probe{}
+	if !mergeDefaults(defaults, value, merged, false) {
+		return value
+	}
+	return merged
+}
 
-	if constants != nil {
-		applyConstants(cr, defaults, constants)
+// StackConstants holds the values that are constant for the applications of a stack and how they are applied
+type StackConstants struct {
+	appsodyv1alpha1.AppsodyApplicationSpec
+	PolicyMode  appsodyv1alpha1.PolicyMode `json:"policyMode,omitempty"`
+	Constraints []StackConstraint          `json:"constraints,omitempty"`
+}
+
+// GetPolicyMode returns the mode of the constants, which are enforced unless stated otherwise
+func (c *StackConstants) GetPolicyMode() appsodyv1alpha1.PolicyMode {
+	if c.PolicyMode == "" {
+		return appsodyv1alpha1.PolicyModeEnforce
+	}
+	return c.PolicyMode
+}
+
+// ValidatePolicyMode ...
+func ValidatePolicyMode(mode appsodyv1alpha1.PolicyMode) error {
+	switch mode {
+	case "", appsodyv1alpha1.PolicyModeEnforce, appsodyv1alpha1.PolicyModeWarn, appsodyv1alpha1.PolicyModeAudit:
+		return nil
+	}
+	return fmt.Errorf("Unknown policy mode `%v`, must be one of %v, %v or %v", mode,
+		appsodyv1alpha1.PolicyModeEnforce, appsodyv1alpha1.PolicyModeWarn, appsodyv1alpha1.PolicyModeAudit)
+}
+
+type constantsPolicy struct {
+	mode       appsodyv1alpha1.PolicyMode
+	violations []appsodyv1alpha1.PolicyViolation
+}
+
+// check records a violation when the value of the application differs from the constant and calls
+// enforce to replace it when the policy is enforced
+func (p *constantsPolicy) check(field string, value, constant interface{}, enforce func()) {
+	if reflect.DeepEqual(value, constant) {
+		return
+	}
+	p.violations = append(p.violations, appsodyv1alpha1.PolicyViolation{
+		Field:    field,
+		Mode:     p.mode,
+		Value:    formatPolicyValue(value),
+		Constant: formatPolicyValue(constant),
+	})
+	if p.mode == appsodyv1alpha1.PolicyModeEnforce {
+		enforce()
 	}
 }
 
-func applyConstants(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1alpha1.AppsodyApplicationSpec, constants *appsodyv1alpha1.AppsodyApplicationSpec) {
+func formatPolicyValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func applyConstants(cr *appsodyv1alpha1.AppsodyApplication, constants *StackConstants) []appsodyv1alpha1.PolicyViolation {
+	p := &constantsPolicy{mode: constants.GetPolicyMode()}
 
 	if constants.Replicas != nil {
-		cr.Spec.Replicas = constants.Replicas
+		p.check("replicas", cr.Spec.Replicas, constants.Replicas, func() {
+			cr.Spec.Replicas = constants.Replicas
+		})
 	}
 
 	if constants.Stack != "" {
-		cr.Spec.Stack = constants.Stack
+		p.check("stack", cr.Spec.Stack, constants.Stack, func() {
+			cr.Spec.Stack = constants.Stack
+		})
 	}
 
 	if constants.ApplicationImage != "" {
-		cr.Spec.ApplicationImage = constants.ApplicationImage
+		p.check("applicationImage", cr.Spec.ApplicationImage, constants.ApplicationImage, func() {
+			cr.Spec.ApplicationImage = constants.ApplicationImage
+		})
 	}
 
 	if constants.PullPolicy != nil {
-		cr.Spec.PullPolicy = constants.PullPolicy
+		p.check("pullPolicy", cr.Spec.PullPolicy, constants.PullPolicy, func() {
+			cr.Spec.PullPolicy = constants.PullPolicy
+		})
 	}
 
 	if constants.PullSecret != nil {
-		cr.Spec.PullSecret = constants.PullSecret
+		p.check("pullSecret", cr.Spec.PullSecret, constants.PullSecret, func() {
+			cr.Spec.PullSecret = constants.PullSecret
+		})
+	}
+
+	if constants.PullSecrets != nil {
+		var missing []string
+		for _, v := range constants.PullSecrets {
+			found := false
+			for _, v2 := range cr.Spec.PullSecrets {
+				if v2 == v {
+					found = true
+				}
+			}
+			if !found {
+				missing = append(missing, v)
+			}
+		}
+		if missing != nil {
+			p.check("pullSecrets", cr.Spec.PullSecrets, constants.PullSecrets, func() {
+				cr.Spec.PullSecrets = append(cr.Spec.PullSecrets, missing...)
+			})
+		}
 	}
 
 	if constants.Expose != nil {
-		cr.Spec.Expose = constants.Expose
+		p.check("expose", cr.Spec.Expose, constants.Expose, func() {
+			cr.Spec.Expose = constants.Expose
+		})
 	}
 
 	if constants.CreateKnativeService != nil {
-		cr.Spec.CreateKnativeService = constants.CreateKnativeService
+		p.check("createKnativeService", cr.Spec.CreateKnativeService, constants.CreateKnativeService, func() {
+			cr.Spec.CreateKnativeService = constants.CreateKnativeService
+		})
 	}
 
 	if constants.ServiceAccountName != nil {
-		cr.Spec.ServiceAccountName = constants.ServiceAccountName
+		p.check("serviceAccountName", cr.Spec.ServiceAccountName, constants.ServiceAccountName, func() {
+			cr.Spec.ServiceAccountName = constants.ServiceAccountName
+		})
+	}
+
+	if constants.PatchServiceAccount != nil {
+		p.check("patchServiceAccount", cr.Spec.PatchServiceAccount, constants.PatchServiceAccount, func() {
+			cr.Spec.PatchServiceAccount = constants.PatchServiceAccount
+		})
 	}
 
 	if constants.Architecture != nil {
-		cr.Spec.Architecture = constants.Architecture
+		p.check("architecture", cr.Spec.Architecture, constants.Architecture, func() {
+			cr.Spec.Architecture = constants.Architecture
+		})
+	}
+
+	if constants.Scheduling != nil {
+		p.check("scheduling", cr.Spec.Scheduling, constants.Scheduling, func() {
+			cr.Spec.Scheduling = constants.Scheduling
+		})
+	}
+
+	if constants.SecurityContext != nil {
+		p.check("securityContext", cr.Spec.SecurityContext, constants.SecurityContext, func() {
+			cr.Spec.SecurityContext = constants.SecurityContext
+		})
 	}
 
 	if constants.ReadinessProbe != nil {
-		cr.Spec.ReadinessProbe = constants.ReadinessProbe
+		p.check("readinessProbe", cr.Spec.ReadinessProbe, constants.ReadinessProbe, func() {
+			cr.Spec.ReadinessProbe = constants.ReadinessProbe
+		})
 	}
 
 	if constants.LivenessProbe != nil {
-		cr.Spec.LivenessProbe = constants.LivenessProbe
+		p.check("livenessProbe", cr.Spec.LivenessProbe, constants.LivenessProbe, func() {
+			cr.Spec.LivenessProbe = constants.LivenessProbe
+		})
 	}
 
 	if constants.EnvFrom != nil {
+		var missing []corev1.EnvFromSource
 		for _, v := range constants.EnvFrom {
-
 			found := false
 			for _, v2 := range cr.Spec.EnvFrom {
-				if v2 == v {
+				if reflect.DeepEqual(v2, v) {
 					found = true
 				}
 			}
 			if !found {
-				cr.Spec.EnvFrom = append(cr.Spec.EnvFrom, v)
+				missing = append(missing, v)
 			}
 		}
+		if missing != nil {
+			p.check("envFrom", cr.Spec.EnvFrom, constants.EnvFrom, func() {
+				cr.Spec.EnvFrom = append(cr.Spec.EnvFrom, missing...)
+			})
+		}
 	}
 
 	if constants.Env != nil {
+		var missing []corev1.EnvVar
 		for _, v := range constants.Env {
 			found := false
 			for _, v2 := range cr.Spec.Env {
@@ -415,12 +1959,18 @@ func applyConstants(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1al
 				}
 			}
 			if !found {
-				cr.Spec.Env = append(cr.Spec.Env, v)
+				missing = append(missing, v)
 			}
 		}
+		if missing != nil {
+			p.check("env", cr.Spec.Env, constants.Env, func() {
+				cr.Spec.Env = append(cr.Spec.Env, missing...)
+			})
+		}
 	}
 
 	if constants.Volumes != nil {
+		var missing []corev1.Volume
 		for _, v := range constants.Volumes {
 			found := false
 			for _, v2 := range cr.Spec.Volumes {
@@ -429,12 +1979,18 @@ func applyConstants(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1al
 				}
 			}
 			if !found {
-				cr.Spec.Volumes = append(cr.Spec.Volumes, v)
+				missing = append(missing, v)
 			}
 		}
+		if missing != nil {
+			p.check("volumes", cr.Spec.Volumes, constants.Volumes, func() {
+				cr.Spec.Volumes = append(cr.Spec.Volumes, missing...)
+			})
+		}
 	}
 
 	if constants.VolumeMounts != nil {
+		var missing []corev1.VolumeMount
 		for _, v := range constants.VolumeMounts {
 			found := false
 			for _, v2 := range cr.Spec.VolumeMounts {
@@ -443,27 +1999,101 @@ func applyConstants(cr *appsodyv1alpha1.AppsodyApplication, defaults appsodyv1al
 				}
 			}
 			if !found {
-				cr.Spec.VolumeMounts = append(cr.Spec.VolumeMounts, v)
+				missing = append(missing, v)
 			}
 		}
+		if missing != nil {
+			p.check("volumeMounts", cr.Spec.VolumeMounts, constants.VolumeMounts, func() {
+				cr.Spec.VolumeMounts = append(cr.Spec.VolumeMounts, missing...)
+			})
+		}
 	}
 
 	if constants.ResourceConstraints != nil {
-		cr.Spec.ResourceConstraints = constants.ResourceConstraints
+		p.check("resourceConstraints", cr.Spec.ResourceConstraints, constants.ResourceConstraints, func() {
+			cr.Spec.ResourceConstraints = constants.ResourceConstraints
+		})
 	}
 
 	if constants.Service != nil {
 		if constants.Service.Type != nil {
-			cr.Spec.Service.Type = constants.Service.Type
+			p.check("service.type", cr.Spec.Service.Type, constants.Service.Type, func() {
+				cr.Spec.Service.Type = constants.Service.Type
+			})
 		}
 		if constants.Service.Port != 0 {
-			cr.Spec.Service.Port = constants.Service.Port
+			p.check("service.port", cr.Spec.Service.Port, constants.Service.Port, func() {
+				cr.Spec.Service.Port = constants.Service.Port
+			})
 		}
 	}
 
 	if constants.Autoscaling != nil {
-		cr.Spec.Autoscaling = constants.Autoscaling
+		p.check("autoscaling", cr.Spec.Autoscaling, constants.Autoscaling, func() {
+			cr.Spec.Autoscaling = constants.Autoscaling
+		})
+	}
+	return p.violations
+}
+
+// UpdatePolicyViolations sets the violations of the constants found in the application, keeping those recorded
+// when enforcing the constants earlier since the values of the application have been replaced since.
+// It returns the violations which were not recorded yet.
+func UpdatePolicyViolations(cr *appsodyv1alpha1.AppsodyApplication, violations []appsodyv1alpha1.PolicyViolation, constants *StackConstants) []appsodyv1alpha1.PolicyViolation {
+	enforced := map[appsodyv1alpha1.PolicyViolation]bool{}
+	if constants != nil && constants.GetPolicyMode() == appsodyv1alpha1.PolicyModeEnforce {
+		// Replay the constants on an empty application to find the value enforced for each field
+		empty := &appsodyv1alpha1.AppsodyApplication{Spec: appsodyv1alpha1.AppsodyApplicationSpec{Service: &appsodyv1alpha1.AppsodyApplicationService{}}}
+		for _, v := range applyConstants(empty, &StackConstants{AppsodyApplicationSpec: constants.AppsodyApplicationSpec, PolicyMode: appsodyv1alpha1.PolicyModeAudit}) {
+			enforced[appsodyv1alpha1.PolicyViolation{Field: v.Field, Constant: v.Constant}] = true
+		}
+		for _, c := range constants.Constraints {
+			if c.Action != appsodyv1alpha1.ConstraintActionClamp {
+				continue
+			}
+			if c.Min != nil || c.Max != nil {
+				enforced[appsodyv1alpha1.PolicyViolation{Field: c.Field, Constant: describeConstraintBounds(c)}] = true
+			}
+			if c.MaxLimitRequestRatio != nil {
+				enforced[appsodyv1alpha1.PolicyViolation{Field: c.Field, Constant: describeLimitRequestRatio(c)}] = true
+			}
+		}
+	}
+
+	updated := append([]appsodyv1alpha1.PolicyViolation{}, violations...)
+	for _, old := range cr.Status.PolicyViolations {
+		if old.Mode != appsodyv1alpha1.PolicyModeEnforce || old.Action == appsodyv1alpha1.ConstraintActionReject ||
+			getPolicyViolation(updated, old.Field, old.Constant) != nil {
+			continue
+		}
+		if enforced[appsodyv1alpha1.PolicyViolation{Field: old.Field, Constant: old.Constant}] {
+			updated = append(updated, old)
+		}
+	}
+	sort.SliceStable(updated, func(i, j int) bool {
+		return updated[i].Field < updated[j].Field
+	})
+
+	var added []appsodyv1alpha1.PolicyViolation
+	for _, v := range violations {
+		if old := getPolicyViolation(cr.Status.PolicyViolations, v.Field, v.Constant); old == nil || *old != v {
+			added = append(added, v)
+		}
+	}
+	if len(updated) == 0 {
+		updated = nil
 	}
+	cr.Status.PolicyViolations = updated
+	return added
+}
+
+func getPolicyViolation(violations []appsodyv1alpha1.PolicyViolation, field, constant string) *appsodyv1alpha1.PolicyViolation {
+	for i := range violations {
+		if violations[i].Field == field && violations[i].Constant == constant {
+			return &violations[i]
+		}
+	}
+	return nil
 }
 
 // GetCondition ...
@@ -477,6 +2107,35 @@ func GetCondition(conditionType appsodyv1alpha1.StatusConditionType, status *app
 	return nil
 }
 
+// UpdateCondition sets the status, reason and message of a condition. Keeps the old `LastTransitionTime` when the
+// status has not changed.
+func UpdateCondition(conditionType appsodyv1alpha1.StatusConditionType, conditionStatus corev1.ConditionStatus, reason string, message string, status *appsodyv1alpha1.AppsodyApplicationStatus) {
+	nowTime := metav1.Now()
+	transitionTime := &nowTime
+	if oldCondition := GetCondition(conditionType, status); oldCondition != nil && oldCondition.Status == conditionStatus {
+		transitionTime = oldCondition.LastTransitionTime
+	}
+
+	SetCondition(appsodyv1alpha1.StatusCondition{
+		LastTransitionTime: transitionTime,
+		LastUpdateTime:     nowTime,
+		Reason:             reason,
+		Message:            message,
+		Status:             conditionStatus,
+		Type:               conditionType,
+	}, status)
+}
+
+// RemoveCondition ...
+func RemoveCondition(conditionType appsodyv1alpha1.StatusConditionType, status *appsodyv1alpha1.AppsodyApplicationStatus) {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			status.Conditions = append(status.Conditions[:i], status.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
 // SetCondition ...
 func SetCondition(condition appsodyv1alpha1.StatusCondition, status *appsodyv1alpha1.AppsodyApplicationStatus) {
 	for i := range status.Conditions {
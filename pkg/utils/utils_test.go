@@ -0,0 +1,1125 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsodyv1alpha1 "github.com/appsody-operator/pkg/apis/appsody/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/authn"
+	imagename "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	name        = "app"
+	namespace   = "appsody"
+	appImage    = "my-image"
+	pullPolicy  = corev1.PullAlways
+	serviceType = corev1.ServiceTypeClusterIP
+	service     = &appsodyv1alpha1.AppsodyApplicationService{Type: &serviceType, Port: 9080}
+	resources   = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	readinessProbe = &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/health", Port: intstr.FromInt(9080)},
+		},
+	}
+	architecture = []string{"amd64", "arm64"}
+	env          = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+)
+
+type Test struct {
+	test     string
+	expected interface{}
+	actual   interface{}
+}
+
+func TestPodSpecParity(t *testing.T) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage:    appImage,
+		PullPolicy:          &pullPolicy,
+		Service:             service,
+		ResourceConstraints: resources,
+		ReadinessProbe:      readinessProbe,
+		Architecture:        architecture,
+		Env:                 env,
+	}
+	cr := createAppsodyApp(name, namespace, spec)
+	os.Setenv(KnativePodSpecFeaturesEnvVar, "affinity")
+	defer os.Unsetenv(KnativePodSpecFeaturesEnvVar)
+
+	pts := &corev1.PodTemplateSpec{}
+	CustomizePodSpec(pts, cr)
+
+	ksvc := &servingv1alpha1.Service{}
+	CustomizeKnativeService(ksvc, cr)
+	revision := ksvc.Spec.Template.Spec.PodSpec
+
+	tests := []Test{
+		{"memory limit", resources.Limits[corev1.ResourceMemory], revision.Containers[0].Resources.Limits[corev1.ResourceMemory]},
+		{"affinity set", true, revision.Affinity != nil && revision.Affinity.NodeAffinity != nil},
+		{"knative container name", "user-container", revision.Containers[0].Name},
+		{"knative probe port", intstr.IntOrString{}, revision.Containers[0].ReadinessProbe.HTTPGet.Port},
+		{"cr probe port untouched", intstr.FromInt(9080), cr.Spec.ReadinessProbe.HTTPGet.Port},
+	}
+	verifyTests("knative", tests, t)
+
+	// Apart from the fields Knative manages itself, both paths must produce the same pod spec
+	workload := pts.Spec.DeepCopy()
+	workload.Containers[0].Name = revision.Containers[0].Name
+	workload.Containers[0].ReadinessProbe = knativeProbe(workload.Containers[0].ReadinessProbe)
+	workload.RestartPolicy = ""
+	workload.DNSPolicy = ""
+	if !reflect.DeepEqual(*workload, revision) {
+		t.Errorf("pod spec parity test expected: (%+v) actual: (%+v)", *workload, revision)
+	}
+}
+
+func TestKnativeRevisionName(t *testing.T) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage:    appImage,
+		PullPolicy:          &pullPolicy,
+		Service:             service,
+		ResourceConstraints: &corev1.ResourceRequirements{},
+	}
+	cr := createAppsodyApp(name, namespace, spec)
+	original := GetKnativeRevisionName(cr)
+
+	cr.Spec.ResourceConstraints = resources
+	resized := GetKnativeRevisionName(cr)
+
+	cr.Spec.Knative = &appsodyv1alpha1.AppsodyApplicationKnative{RevisionSuffix: "v2"}
+
+	tests := []Test{
+		{"stable name", original, GetKnativeRevisionName(createAppsodyApp(name, namespace, spec))},
+		{"resources change the name", false, original == resized},
+		{"suffix", name + "-v2", GetKnativeRevisionName(cr)},
+	}
+	verifyTests("revision name", tests, t)
+}
+
+func TestUnstructuredKnativeService(t *testing.T) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage:    appImage,
+		PullPolicy:          &pullPolicy,
+		Service:             service,
+		ResourceConstraints: resources,
+		Env:                 env,
+	}
+	cr := createAppsodyApp(name, namespace, spec)
+
+	ksvc := NewKnativeService("serving.knative.dev/v1", cr.ObjectMeta)
+	ksvc.Object["spec"] = map[string]interface{}{
+		"futureField": "kept",
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name + "-old"},
+			"spec": map[string]interface{}{
+				"containerConcurrency": int64(10),
+				"futureField":          "kept",
+				"containers": []interface{}{map[string]interface{}{
+					"image":       "old-image",
+					"futureField": "kept",
+				}},
+			},
+		},
+	}
+	ksvc.SetAnnotations(map[string]string{"serving.knative.dev/creator": "admin"})
+	if err := CustomizeUnstructuredKnativeService(ksvc, cr); err != nil {
+		t.Fatalf("CustomizeUnstructuredKnativeService: (%v)", err)
+	}
+
+	nested := func(fields ...string) interface{} {
+		value, _, _ := unstructured.NestedFieldNoCopy(ksvc.Object, fields...)
+		return value
+	}
+	container := nested("spec", "template", "spec", "containers").([]interface{})[0].(map[string]interface{})
+	tests := []Test{
+		{"api version", "serving.knative.dev/v1", ksvc.GetAPIVersion()},
+		{"revision name", GetKnativeRevisionName(cr), nested("spec", "template", "metadata", "name")},
+		{"image", appImage, container["image"]},
+		{"container name", "user-container", container["name"]},
+		{"env", "LOG_LEVEL", container["env"].([]interface{})[0].(map[string]interface{})["name"]},
+		{"unknown container field", "kept", container["futureField"]},
+		{"unknown pod field", "kept", nested("spec", "template", "spec", "futureField")},
+		{"unknown spec field", "kept", nested("spec", "futureField")},
+		{"defaulted field", int64(10), nested("spec", "template", "spec", "containerConcurrency")},
+		{"annotation of another controller", "admin", ksvc.GetAnnotations()["serving.knative.dev/creator"]},
+	}
+	verifyTests("unstructured knative", tests, t)
+}
+
+func TestCustomizePersistence(t *testing.T) {
+	storageClassName := "fast"
+	blockMode := corev1.PersistentVolumeBlock
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		Storage: &appsodyv1alpha1.AppsodyApplicationStorage{
+			Size:      "1Gi",
+			MountPath: "/data",
+			Volumes: []appsodyv1alpha1.AppsodyApplicationVolume{
+				{
+					Name:             "logs",
+					Size:             "5Gi",
+					MountPath:        "/logs",
+					StorageClassName: &storageClassName,
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				},
+				{Name: "raw", Size: "10Gi", MountPath: "/dev/xvda", VolumeMode: &blockMode},
+			},
+		},
+	}
+	cr := createAppsodyApp(name, namespace, spec)
+	if err := ValidateStorage(cr); err != nil {
+		t.Fatalf("ValidateStorage: (%v)", err)
+	}
+
+	statefulSet := &appsv1.StatefulSet{}
+	statefulSet.Spec.Template.Spec.Containers = []corev1.Container{{}}
+	CustomizePersistence(statefulSet, cr)
+
+	templates := statefulSet.Spec.VolumeClaimTemplates
+	container := statefulSet.Spec.Template.Spec.Containers[0]
+	logsSize, _ := GetRequestedStorageSize(cr, "logs")
+	tests := []Test{
+		{"claim templates", 3, len(templates)},
+		{"legacy claim name", "pvc", templates[0].Name},
+		{"legacy access mode", []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, templates[0].Spec.AccessModes},
+		{"storage class", &storageClassName, templates[1].Spec.StorageClassName},
+		{"access modes", []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, templates[1].Spec.AccessModes},
+		{"requested size", "5Gi", logsSize.String()},
+		{"volume mounts", []corev1.VolumeMount{{Name: "pvc", MountPath: "/data"}, {Name: "logs", MountPath: "/logs"}}, container.VolumeMounts},
+		{"volume devices", []corev1.VolumeDevice{{Name: "raw", DevicePath: "/dev/xvda"}}, container.VolumeDevices},
+		{"templates match", true, HasVolumeClaimTemplates(statefulSet, cr)},
+	}
+	verifyTests("persistence", tests, t)
+
+	cr.Spec.Storage.Volumes = cr.Spec.Storage.Volumes[:1]
+	verifyTests("persistence", []Test{{"templates changed", false, HasVolumeClaimTemplates(statefulSet, cr)}}, t)
+
+	cr.Spec.Storage.Volumes = append(cr.Spec.Storage.Volumes, appsodyv1alpha1.AppsodyApplicationVolume{Name: "pvc", Size: "1Gi", MountPath: "/other"})
+	if err := ValidateStorage(cr); err == nil {
+		t.Error("ValidateStorage accepted a duplicate volume name")
+	}
+
+	replicas := int32(2)
+	sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "app"}, Spec: appsv1.StatefulSetSpec{Replicas: &replicas}}
+	claim := func(n string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: n}}
+	}
+	claimTests := []Test{
+		{"replica claim", true, IsVolumeClaimOf(claim("data-app-1"), "data", sts)},
+		{"claim of other statefulset", false, IsVolumeClaimOf(claim("data-app-x-0"), "data", sts)},
+		{"claim beyond replicas", false, IsVolumeClaimOf(claim("data-app-2"), "data", sts)},
+		{"padded ordinal", false, IsVolumeClaimOf(claim("data-app-01"), "data", sts)},
+	}
+	verifyTests("volume claims", claimTests, t)
+}
+
+func TestMetadataPropagation(t *testing.T) {
+	os.Setenv(MetadataPrefixesAllowEnvVar, "app.kubernetes.io/, example.com/, service.beta.kubernetes.io/")
+	os.Setenv(MetadataPrefixesDenyEnvVar, "example.com/internal")
+	defer os.Unsetenv(MetadataPrefixesAllowEnvVar)
+	defer os.Unsetenv(MetadataPrefixesDenyEnvVar)
+
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{Service: service}
+	cr := createAppsodyApp(name, namespace, spec)
+	cr.Labels = map[string]string{
+		"app.kubernetes.io/part-of":    "shop",
+		"app.kubernetes.io/managed-by": "helm",
+		"example.com/cost-center":      "1234",
+		"example.com/internal-id":      "42",
+		"team":                         "payments",
+	}
+	cr.Annotations = map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+		"kubectl.kubernetes.io/last-applied-configuration":      "{}",
+	}
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Labels:      map[string]string{"other-controller": "value"},
+		Annotations: map[string]string{"other-controller/annotation": "value"},
+	}}
+	CustomizeService(svc, cr)
+
+	tests := []Test{
+		{"labels", map[string]string{
+			"app.kubernetes.io/name":       name,
+			"app.kubernetes.io/managed-by": "appsody-operator",
+			"app.kubernetes.io/part-of":    "shop",
+			"example.com/cost-center":      "1234",
+			"other-controller":             "value",
+		}, svc.Labels},
+		{"annotations", map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+			"other-controller/annotation":                           "value",
+		}, svc.Annotations},
+		{"selector", map[string]string{"app.kubernetes.io/name": name}, svc.Spec.Selector},
+	}
+	verifyTests("metadata", tests, t)
+}
+
+func TestScheduling(t *testing.T) {
+	tolerations := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "apps", Effect: corev1.TaintEffectNoSchedule}}
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage:    appImage,
+		PullPolicy:          &pullPolicy,
+		Service:             service,
+		ResourceConstraints: resources,
+		Architecture:        architecture,
+		Scheduling: &appsodyv1alpha1.AppsodyApplicationScheduling{
+			NodeSelector:      map[string]string{"disktype": "ssd"},
+			Tolerations:       tolerations,
+			PriorityClassName: "high",
+			SpreadReplicas:    appsodyv1alpha1.SpreadReplicasZone,
+		},
+	}
+	cr := createAppsodyApp(name, namespace, spec)
+
+	pts := &corev1.PodTemplateSpec{}
+	CustomizePodSpec(pts, cr)
+	ksvc := &servingv1alpha1.Service{}
+	CustomizeKnativeService(ksvc, cr)
+	revision := ksvc.Spec.Template.Spec.PodSpec
+
+	antiAffinity := pts.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	tests := []Test{
+		{"node selector", map[string]string{"disktype": "ssd"}, pts.Spec.NodeSelector},
+		{"tolerations", tolerations, pts.Spec.Tolerations},
+		{"priority class", "high", pts.Spec.PriorityClassName},
+		{"architecture kept", architecture, pts.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values},
+		{"spread topology", "failure-domain.beta.kubernetes.io/zone", antiAffinity.TopologyKey},
+		{"spread selector", map[string]string{"app.kubernetes.io/name": name}, antiAffinity.LabelSelector.MatchLabels},
+		{"knative priority class", "", revision.PriorityClassName},
+		{"knative affinity without flag", (*corev1.Affinity)(nil), revision.Affinity},
+		{"knative node selector without flag", map[string]string(nil), revision.NodeSelector},
+		{"knative tolerations without flag", []corev1.Toleration(nil), revision.Tolerations},
+		{"ignored knative fields", []string{"architecture", "scheduling.spreadReplicas", "scheduling.nodeSelector", "scheduling.tolerations"}, GetIgnoredKnativeFields(cr)},
+	}
+	verifyTests("scheduling", tests, t)
+
+	os.Setenv(KnativePodSpecFeaturesEnvVar, "affinity, nodeselector,Tolerations")
+	defer os.Unsetenv(KnativePodSpecFeaturesEnvVar)
+	CustomizeKnativeService(ksvc, cr)
+	revision = ksvc.Spec.Template.Spec.PodSpec
+	revisionAntiAffinity := revision.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	tests = []Test{
+		{"knative spread selector", map[string]string{"serving.knative.dev/service": name}, revisionAntiAffinity.LabelSelector.MatchLabels},
+		{"knative node selector", map[string]string{"disktype": "ssd"}, revision.NodeSelector},
+		{"knative tolerations", tolerations, revision.Tolerations},
+		{"no ignored knative fields", []string{}, GetIgnoredKnativeFields(cr)},
+	}
+	verifyTests("scheduling with knative flags", tests, t)
+
+	cr.Spec.Architecture = nil
+	cr.Spec.Scheduling = nil
+	CustomizePodSpec(pts, cr)
+	tests = []Test{
+		{"affinity removed", (*corev1.Affinity)(nil), pts.Spec.Affinity},
+		{"node selector removed", map[string]string(nil), pts.Spec.NodeSelector},
+		{"priority class removed", "", pts.Spec.PriorityClassName},
+	}
+	verifyTests("scheduling", tests, t)
+}
+
+func TestSecurityContext(t *testing.T) {
+	readOnly, privileged := true, false
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage:    appImage,
+		PullPolicy:          &pullPolicy,
+		Service:             service,
+		ResourceConstraints: resources,
+		SecurityContext: &appsodyv1alpha1.AppsodyApplicationSecurity{
+			Container: &corev1.SecurityContext{ReadOnlyRootFilesystem: &readOnly},
+		},
+	}
+	cr := createAppsodyApp(name, namespace, spec)
+
+	// Constants enforce the restricted profile on top of the settings of the application
+	constants := &StackConstants{AppsodyApplicationSpec: appsodyv1alpha1.AppsodyApplicationSpec{
+		SecurityContext: &appsodyv1alpha1.AppsodyApplicationSecurity{
+			Profile:   appsodyv1alpha1.SecurityProfileRestricted,
+			Container: &corev1.SecurityContext{ReadOnlyRootFilesystem: &readOnly, Privileged: &privileged},
+		},
+	}}
+	InitAndValidate(cr, appsodyv1alpha1.AppsodyApplicationSpec{}, constants)
+
+	pts := &corev1.PodTemplateSpec{}
+	CustomizePodSpec(pts, cr)
+	container := pts.Spec.Containers[0].SecurityContext
+	ksvc := &servingv1alpha1.Service{}
+	CustomizeKnativeService(ksvc, cr)
+	revisionName := ksvc.Spec.Template.Name
+	tests := []Test{
+		{"run as non root", true, *pts.Spec.SecurityContext.RunAsNonRoot},
+		{"read only root filesystem", true, *container.ReadOnlyRootFilesystem},
+		{"privileged", false, *container.Privileged},
+		{"privilege escalation", false, *container.AllowPrivilegeEscalation},
+		{"capabilities", []corev1.Capability{"ALL"}, container.Capabilities.Drop},
+		{"seccomp", "runtime/default", pts.Annotations[SeccompPodAnnotation]},
+		{"knative seccomp", "runtime/default", ksvc.Spec.Template.Annotations[SeccompPodAnnotation]},
+		{"knative run as non root", true, *ksvc.Spec.Template.Spec.SecurityContext.RunAsNonRoot},
+		{"constants untouched", (*corev1.Capabilities)(nil), constants.SecurityContext.Container.Capabilities},
+	}
+	verifyTests("security context", tests, t)
+
+	profile := "profiles/app.json"
+	cr.Spec.SecurityContext.SeccompProfile = &appsodyv1alpha1.AppsodyApplicationSeccompProfile{
+		Type:             appsodyv1alpha1.SeccompProfileTypeLocalhost,
+		LocalhostProfile: &profile,
+	}
+	CustomizePodSpec(pts, cr)
+	CustomizeKnativeService(ksvc, cr)
+	tests = []Test{
+		{"localhost seccomp", "localhost/profiles/app.json", pts.Annotations[SeccompPodAnnotation]},
+		{"knative localhost seccomp", "localhost/profiles/app.json", ksvc.Spec.Template.Annotations[SeccompPodAnnotation]},
+		{"knative revision renamed", false, revisionName == ksvc.Spec.Template.Name},
+	}
+	verifyTests("security context", tests, t)
+
+	cr.Spec.SecurityContext = nil
+	CustomizePodSpec(pts, cr)
+	CustomizeKnativeService(ksvc, cr)
+	tests = []Test{
+		{"pod removed", (*corev1.PodSecurityContext)(nil), pts.Spec.SecurityContext},
+		{"container removed", (*corev1.SecurityContext)(nil), pts.Spec.Containers[0].SecurityContext},
+		{"seccomp removed", false, pts.Annotations[SeccompPodAnnotation] != ""},
+		{"knative seccomp removed", false, ksvc.Spec.Template.Annotations[SeccompPodAnnotation] != ""},
+	}
+	verifyTests("security context", tests, t)
+}
+
+// Helper Functions
+func TestPolicyModes(t *testing.T) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage: appImage,
+		PullPolicy:       &pullPolicy,
+		Service:          service,
+		Env:              env,
+	}
+	ifNotPresent := corev1.PullIfNotPresent
+	constants := &StackConstants{AppsodyApplicationSpec: appsodyv1alpha1.AppsodyApplicationSpec{
+		PullPolicy:          &ifNotPresent,
+		ResourceConstraints: resources,
+		Env:                 []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+	}}
+
+	// Warnings and audits keep the values of the application
+	for _, mode := range []appsodyv1alpha1.PolicyMode{appsodyv1alpha1.PolicyModeWarn, appsodyv1alpha1.PolicyModeAudit} {
+		constants.PolicyMode = mode
+		cr := createAppsodyApp(name, namespace, spec)
+		violations := InitAndValidate(cr, appsodyv1alpha1.AppsodyApplicationSpec{}, constants)
+		tests := []Test{
+			{"violations", 2, len(violations)},
+			{"field", "pullPolicy", violations[0].Field},
+			{"mode", mode, violations[0].Mode},
+			{"value", `"Always"`, violations[0].Value},
+			{"constant", `"IfNotPresent"`, violations[0].Constant},
+			{"resources", "resourceConstraints", violations[1].Field},
+			{"pull policy kept", corev1.PullAlways, *cr.Spec.PullPolicy},
+			{"resources kept", &corev1.ResourceRequirements{}, cr.Spec.ResourceConstraints},
+		}
+		verifyTests(string(mode), tests, t)
+	}
+
+	// Enforced violations stay recorded after the values of the application have been replaced
+	constants.PolicyMode = ""
+	cr := createAppsodyApp(name, namespace, spec)
+	violations := InitAndValidate(cr, appsodyv1alpha1.AppsodyApplicationSpec{}, constants)
+	added := UpdatePolicyViolations(cr, violations, constants)
+	tests := []Test{
+		{"pull policy enforced", corev1.PullIfNotPresent, *cr.Spec.PullPolicy},
+		{"resources enforced", resources, cr.Spec.ResourceConstraints},
+		{"env of application kept", "debug", cr.Spec.Env[0].Value},
+		{"added", 2, len(added)},
+		{"mode", appsodyv1alpha1.PolicyModeEnforce, cr.Status.PolicyViolations[0].Mode},
+	}
+	verifyTests("enforce", tests, t)
+
+	violations = InitAndValidate(cr, appsodyv1alpha1.AppsodyApplicationSpec{}, constants)
+	added = UpdatePolicyViolations(cr, violations, constants)
+	tests = []Test{
+		{"compliant", 0, len(violations)},
+		{"nothing added", 0, len(added)},
+		{"still recorded", 2, len(cr.Status.PolicyViolations)},
+	}
+	verifyTests("enforce again", tests, t)
+
+	// Records are dropped once the constant no longer applies
+	constants.PullPolicy = nil
+	UpdatePolicyViolations(cr, InitAndValidate(cr, appsodyv1alpha1.AppsodyApplicationSpec{}, constants), constants)
+	tests = []Test{
+		{"recorded", 1, len(cr.Status.PolicyViolations)},
+		{"field", "resourceConstraints", cr.Status.PolicyViolations[0].Field},
+	}
+	verifyTests("constant removed", tests, t)
+}
+
+func TestConstraints(t *testing.T) {
+	replicas := int32(20)
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage: "docker.io/my-image",
+		Replicas:         &replicas,
+		PullPolicy:       &pullPolicy,
+		Service:          service,
+		ResourceConstraints: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+	}
+	constants := &StackConstants{}
+	err := json.Unmarshal([]byte(`{"constraints": [
+		{"field": "resourceConstraints.requests.memory", "min": "256Mi", "max": "4Gi", "action": "clamp"},
+		{"field": "resourceConstraints.limits.memory", "maxLimitRequestRatio": 2, "action": "clamp"},
+		{"field": "replicas", "max": 10, "action": "clamp"},
+		{"field": "readinessProbe", "required": true},
+		{"field": "applicationImage", "pattern": "^registry\\.example\\.com/"}
+	]}`), constants)
+	if err != nil {
+		t.Fatalf("Unmarshal constraints: (%v)", err)
+	}
+	if err = ValidateStackConstants(constants); err != nil {
+		t.Fatalf("Validate constraints: (%v)", err)
+	}
+
+	// Audits only report the fields out of bounds
+	constants.PolicyMode = appsodyv1alpha1.PolicyModeAudit
+	cr := createAppsodyApp(name, namespace, spec)
+	violations, err := ApplyConstraints(cr, constants)
+	tests := []Test{
+		{"violations", 5, len(violations)},
+		{"not rejected", nil, err},
+		{"request", "resourceConstraints.requests.memory", violations[0].Field},
+		{"bounds", "between 256Mi and 4Gi", violations[0].Constant},
+		{"value", `"128Mi"`, violations[0].Value},
+		{"ratio", "at most 2 times the request", violations[1].Constant},
+		{"replicas kept", int32(20), *cr.Spec.Replicas},
+	}
+	verifyTests("audit constraints", tests, t)
+
+	// Enforcing clamps the bounds and rejects the other violations
+	constants.PolicyMode = ""
+	violations, err = ApplyConstraints(cr, constants)
+	requests, limits := cr.Spec.ResourceConstraints.Requests, cr.Spec.ResourceConstraints.Limits
+	tests = []Test{
+		{"violations", 5, len(violations)},
+		{"request clamped", "256Mi", requests.Memory().String()},
+		{"limit clamped", "512Mi", limits.Memory().String()},
+		{"replicas clamped", int32(10), *cr.Spec.Replicas},
+		{"clamp action", appsodyv1alpha1.ConstraintActionClamp, violations[0].Action},
+		{"reject action", appsodyv1alpha1.ConstraintActionReject, violations[3].Action},
+		{"rejected", true, err != nil && strings.Contains(err.Error(), "readinessProbe must be set")},
+		{"spec untouched", "128Mi", spec.ResourceConstraints.Requests.Memory().String()},
+	}
+	verifyTests("enforce constraints", tests, t)
+
+	// Clamped values meet the constraints
+	cr.Spec.ReadinessProbe = readinessProbe
+	cr.Spec.ApplicationImage = "registry.example.com/my-image"
+	violations, err = ApplyConstraints(cr, constants)
+	tests = []Test{
+		{"violations", 0, len(violations)},
+		{"not rejected", nil, err},
+	}
+	verifyTests("compliant constraints", tests, t)
+
+	invalid := StackConstraint{Field: "pullPolicy", Max: resource.NewQuantity(1, resource.DecimalSI)}
+	verifyTests("invalid constraint", []Test{{"min and max", true, ValidateStackConstraint(invalid) != nil}}, t)
+}
+
+func TestMergeDefaults(t *testing.T) {
+	nodePort := corev1.ServiceTypeNodePort
+	delay, threshold, cpu := int32(30), int32(5), int32(80)
+	defaults := appsodyv1alpha1.AppsodyApplicationSpec{
+		ReadinessProbe: &corev1.Probe{
+			Handler:          corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(9080)}},
+			FailureThreshold: threshold,
+		},
+		LivenessProbe: readinessProbe,
+		Service:       &appsodyv1alpha1.AppsodyApplicationService{Type: &nodePort, Port: 9080},
+		ResourceConstraints: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+		},
+		Autoscaling: &appsodyv1alpha1.AppsodyApplicationAutoScaling{TargetCPUUtilizationPercentage: &cpu, MaxReplicas: 5},
+	}
+
+	cases := []struct {
+		name  string
+		spec  appsodyv1alpha1.AppsodyApplicationSpec
+		tests func(spec appsodyv1alpha1.AppsodyApplicationSpec) []Test
+	}{
+		{
+			name: "unset fields take the defaults",
+			spec: appsodyv1alpha1.AppsodyApplicationSpec{},
+			tests: func(spec appsodyv1alpha1.AppsodyApplicationSpec) []Test {
+				return []Test{
+					{"probe path", "/ready", spec.ReadinessProbe.HTTPGet.Path},
+					{"service type", nodePort, *spec.Service.Type},
+					{"service port", int32(9080), spec.Service.Port},
+					{"max replicas", int32(5), spec.Autoscaling.MaxReplicas},
+				}
+			},
+		},
+		{
+			name: "scalar fields override the defaults",
+			spec: appsodyv1alpha1.AppsodyApplicationSpec{
+				ReadinessProbe: &corev1.Probe{InitialDelaySeconds: delay},
+				Service:        &appsodyv1alpha1.AppsodyApplicationService{Port: 3000},
+				Autoscaling:    &appsodyv1alpha1.AppsodyApplicationAutoScaling{MaxReplicas: 10},
+			},
+			tests: func(spec appsodyv1alpha1.AppsodyApplicationSpec) []Test {
+				return []Test{
+					{"probe delay", delay, spec.ReadinessProbe.InitialDelaySeconds},
+					{"probe path kept", "/ready", spec.ReadinessProbe.HTTPGet.Path},
+					{"probe threshold kept", threshold, spec.ReadinessProbe.FailureThreshold},
+					{"service port", int32(3000), spec.Service.Port},
+					{"service type kept", nodePort, *spec.Service.Type},
+					{"max replicas", int32(10), spec.Autoscaling.MaxReplicas},
+					{"target cpu kept", cpu, *spec.Autoscaling.TargetCPUUtilizationPercentage},
+				}
+			},
+		},
+		{
+			name: "nested objects are merged",
+			spec: appsodyv1alpha1.AppsodyApplicationSpec{
+				ReadinessProbe: &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/health/ready"}}},
+				ResourceConstraints: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				},
+			},
+			tests: func(spec appsodyv1alpha1.AppsodyApplicationSpec) []Test {
+				return []Test{
+					{"probe path", "/health/ready", spec.ReadinessProbe.HTTPGet.Path},
+					{"probe port kept", intstr.FromInt(9080), spec.ReadinessProbe.HTTPGet.Port},
+					{"memory request", "1Gi", spec.ResourceConstraints.Requests.Memory().String()},
+					{"cpu request kept", "100m", spec.ResourceConstraints.Requests.Cpu().String()},
+					{"memory limit", "2Gi", spec.ResourceConstraints.Limits.Memory().String()},
+				}
+			},
+		},
+		{
+			name: "a probe handler replaces the default handler",
+			spec: appsodyv1alpha1.AppsodyApplicationSpec{
+				LivenessProbe: &corev1.Probe{Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"check"}}}},
+			},
+			tests: func(spec appsodyv1alpha1.AppsodyApplicationSpec) []Test {
+				return []Test{
+					{"exec", []string{"check"}, spec.LivenessProbe.Exec.Command},
+					{"default handler dropped", (*corev1.HTTPGetAction)(nil), spec.LivenessProbe.HTTPGet},
+				}
+			},
+		},
+	}
+	for _, c := range cases {
+		cr := createAppsodyApp(name, namespace, c.spec)
+		InitAndValidate(cr, defaults, nil)
+		verifyTests(c.name, c.tests(cr.Spec), t)
+
+		// Merging again is a no-op, so the application is not updated on every reconcile
+		spec := cr.Spec.DeepCopy()
+		InitAndValidate(cr, defaults, nil)
+		verifyTests(c.name, []Test{{"idempotent", spec, &cr.Spec}}, t)
+	}
+	verifyTests("defaults untouched", []Test{{"service port", int32(9080), defaults.Service.Port}}, t)
+
+	// Profiles override the defaults with their zero values too
+	replicas, expose := int32(2), true
+	defaults.Replicas, defaults.Expose, defaults.ApplicationImage = &replicas, &expose, "my-image"
+	zero, hidden := int32(0), false
+	merged := ApplyProfile(defaults, appsodyv1alpha1.AppsodyApplicationSpec{Replicas: &zero, Expose: &hidden})
+	tests := []Test{
+		{"replicas", int32(0), *merged.Replicas},
+		{"expose", false, *merged.Expose},
+		{"image kept", "my-image", merged.ApplicationImage},
+		{"service port kept", int32(9080), merged.Service.Port},
+	}
+	verifyTests("profile zero values", tests, t)
+}
+
+func TestImageInspector(t *testing.T) {
+	// In-process registry requiring the credentials of the pull secret
+	requests, blobs := 0, 0
+	handler := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			blobs++
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatalf("Create image: (%v)", err)
+	}
+	img, err = mutate.Config(img, v1.Config{
+		Labels:       map[string]string{StackIDLabel: "java-microprofile", StackVersionLabel: "0.2.11"},
+		ExposedPorts: map[string]struct{}{"9443/tcp": {}, "9080/tcp": {}, "53/udp": {}},
+	})
+	if err != nil {
+		t.Fatalf("Configure image: (%v)", err)
+	}
+	ref, err := imagename.ParseReference(host+"/app:latest", imagename.WeakValidation)
+	if err != nil {
+		t.Fatalf("Parse reference: (%v)", err)
+	}
+	if err = remote.Write(ref, img, remote.WithAuth(&authn.Basic{Username: "user", Password: "secret"})); err != nil {
+		t.Fatalf("Push image: (%v)", err)
+	}
+	digest, _ := img.Digest()
+
+	inspector := NewImageInspector(nil)
+	now := time.Now()
+	inspector.now = func() time.Time { return now }
+	_, err = inspector.Inspect(host+"/app:latest", nil)
+	failed := requests
+	_, cachedErr := inspector.Inspect(host+"/app:latest", nil)
+	duringBackoff := requests
+	retry := inspector.GetRetryInterval(host+"/app:latest", nil)
+	now = now.Add(retry)
+	inspector.Inspect(host+"/app:latest", nil)
+	anonymousTests := []Test{
+		{"unauthorized", true, err != nil},
+		{"cached failure", err, cachedErr},
+		{"no request during backoff", failed, duringBackoff},
+		{"backoff", minInspectBackoff, retry},
+		{"retried after backoff", true, requests > failed},
+		{"doubled backoff", 2 * minInspectBackoff, inspector.GetRetryInterval(host+"/app:latest", nil)},
+	}
+	verifyTests("anonymous", anonymousTests, t)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	secrets := []corev1.Secret{{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths": {"http://` + host + `/v2/": {"auth": "` + auth + `"}}}`)},
+	}}
+	metadata, err := inspector.Inspect(host+"/app:latest", secrets)
+	if err != nil {
+		t.Fatalf("Inspect image: (%v)", err)
+	}
+	tests := []Test{
+		{"digest", digest.String(), metadata.Digest},
+		{"stack", "java-microprofile", metadata.StackID},
+		{"stack version", "0.2.11", metadata.StackVersion},
+		{"ports", []int32{9080, 9443}, metadata.Ports},
+	}
+	verifyTests("inspect", tests, t)
+
+	// Indexes are read from the image of their first linux platform
+	index, err := random.Index(64, 1, 3)
+	if err != nil {
+		t.Fatalf("Create index: (%v)", err)
+	}
+	manifest, _ := index.IndexManifest()
+	manifest.Manifests[0].Platform = &v1.Platform{OS: "unknown", Architecture: "unknown"}
+	manifest.Manifests[1].Platform = &v1.Platform{OS: "linux", Architecture: "arm64"}
+	manifest.Manifests[2].Platform = &v1.Platform{OS: "linux", Architecture: "amd64"}
+	indexRef, _ := imagename.ParseReference(host+"/multi:latest", imagename.WeakValidation)
+	if err = remote.WriteIndex(indexRef, index, remote.WithAuth(&authn.Basic{Username: "user", Password: "secret"})); err != nil {
+		t.Fatalf("Push index: (%v)", err)
+	}
+	multi, err := inspector.Inspect(host+"/multi:latest", secrets)
+	if err != nil {
+		t.Fatalf("Inspect index: (%v)", err)
+	}
+	verifyTests("index", []Test{{"architectures", []string{"arm64", "amd64"}, multi.Architectures}}, t)
+
+	// The config is only fetched once per digest, tags are only looked up once within their TTL, and pinned
+	// images don't need the registry
+	fetched, looked := blobs, requests
+	if _, err = inspector.Inspect(host+"/app:latest", secrets); err != nil {
+		t.Fatalf("Inspect image: (%v)", err)
+	}
+	resolved, err := inspector.ResolveDigest(host+"/app:latest", secrets)
+	if err != nil {
+		t.Fatalf("Resolve digest: (%v)", err)
+	}
+	cachedTag := requests
+	now = now.Add(tagDigestTTL)
+	if _, err = inspector.ResolveDigest(host+"/app:latest", secrets); err != nil {
+		t.Fatalf("Resolve digest: (%v)", err)
+	}
+	sent := requests
+	pinned, err := inspector.Inspect(host+"/app@"+digest.String(), nil)
+	if err != nil {
+		t.Fatalf("Inspect pinned image: (%v)", err)
+	}
+	tests = []Test{
+		{"resolved digest", digest.String(), resolved},
+		{"cached config", fetched, blobs},
+		{"cached tag", looked, cachedTag},
+		{"expired tag", true, sent > cachedTag},
+		{"pinned requests", sent, requests},
+		{"pinned stack", "java-microprofile", pinned.StackID},
+	}
+	verifyTests("cache", tests, t)
+
+	// Requests to registries that don't answer are cancelled after the timeout
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer hanging.Close()
+	transport := &timeoutTransport{base: http.DefaultTransport, timeout: 50 * time.Millisecond}
+	req, _ := http.NewRequest(http.MethodGet, hanging.URL+"/v2/", nil)
+	start := time.Now()
+	_, err = transport.RoundTrip(req)
+	verifyTests("timeout", []Test{{"failed", true, err != nil}, {"bounded", true, time.Since(start) < time.Second}}, t)
+}
+
+func TestImagePolicy(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{ApplicationImage: "my-image:1.0", ImagePolicy: &appsodyv1alpha1.AppsodyApplicationImagePolicy{}}
+	app := createAppsodyApp(name, namespace, spec)
+
+	pinned, err := PinImageDigest("my-image:1.0", digest)
+	if err != nil {
+		t.Fatalf("Pin image: (%v)", err)
+	}
+	unresolved := GetImage(app)
+	interval, _ := GetImagePollInterval(app)
+	changed := RecordImageDigest(app, pinned, digest)
+	unchanged := RecordImageDigest(app, pinned, digest)
+	tests := []Test{
+		{"pinned", "index.docker.io/library/my-image@" + digest, pinned},
+		{"unresolved image", "my-image:1.0", unresolved},
+		{"default poll interval", DefaultImagePollInterval, interval},
+		{"changed", true, changed},
+		{"unchanged", false, unchanged},
+		{"resolved image", pinned, GetImage(app)},
+	}
+	verifyTests("resolve", tests, t)
+
+	// Digests resolved for another image are not deployed
+	app.Spec.ApplicationImage = "my-image:2.0"
+	tests = []Test{{"new image", "my-image:2.0", GetImage(app)}}
+	verifyTests("new image", tests, t)
+
+	for i := 0; i < maxImageDigests+2; i++ {
+		RecordImageDigest(app, pinned, fmt.Sprintf("sha256:%064d", i))
+	}
+	last := app.Status.ImageDigests[len(app.Status.ImageDigests)-1]
+	tests = []Test{
+		{"history limit", maxImageDigests, len(app.Status.ImageDigests)},
+		{"last digest", fmt.Sprintf("sha256:%064d", maxImageDigests+1), last.Digest},
+	}
+	verifyTests("history", tests, t)
+
+	app.Spec.ImagePolicy = &appsodyv1alpha1.AppsodyApplicationImagePolicy{PollInterval: "0s"}
+	errPollInterval := ValidateImagePolicy(app)
+	app.Spec.ImagePolicy = &appsodyv1alpha1.AppsodyApplicationImagePolicy{PollInterval: "1h", ImageStreamTag: "my-app"}
+	errImageStreamTag := ValidateImagePolicy(app)
+	app.Spec.ImagePolicy.ImageStreamTag = "my-app:latest"
+	tests = []Test{
+		{"invalid poll interval", true, errPollInterval != nil},
+		{"invalid image stream tag", true, errImageStreamTag != nil},
+		{"valid policy", nil, ValidateImagePolicy(app)},
+	}
+	verifyTests("validate", tests, t)
+}
+
+func TestArchitectures(t *testing.T) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage:    appImage,
+		PullPolicy:          &pullPolicy,
+		Service:             service,
+		ResourceConstraints: resources,
+		Architecture:        []string{"ppc64le", "arm64", "amd64"},
+	}
+	app := createAppsodyApp(name, namespace, spec)
+
+	uninspected := GetArchitectures(app)
+	app.Status.ImageArchitectures = []string{"amd64", "arm64"}
+	supported := GetArchitectures(app)
+	affinity := &corev1.Affinity{}
+	CustomizeAffinity(affinity, app)
+	app.Spec.Architecture = nil
+	derived := GetArchitectures(app)
+
+	// Knative revisions only get the node affinity of an explicit architecture
+	os.Setenv(KnativePodSpecFeaturesEnvVar, "affinity")
+	defer os.Unsetenv(KnativePodSpecFeaturesEnvVar)
+	derivedKnative := &corev1.PodSpec{}
+	customizeKnativePodSpec(derivedKnative, app)
+	app.Spec.Architecture = []string{"arm64"}
+	explicitKnative := &corev1.PodSpec{}
+	customizeKnativePodSpec(explicitKnative, app)
+	app.Spec.Architecture = nil
+	app.Spec.Scheduling = &appsodyv1alpha1.AppsodyApplicationScheduling{SpreadReplicas: appsodyv1alpha1.SpreadReplicasNode}
+	spreadKnative := &corev1.PodSpec{}
+	customizeKnativePodSpec(spreadKnative, app)
+	app.Spec.Scheduling = nil
+	app.Spec.Architecture = []string{"s390x"}
+	unsupported := GetArchitectures(app)
+
+	term := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	tests := []Test{
+		{"uninspected", []string{"ppc64le", "arm64", "amd64"}, uninspected},
+		{"supported", []string{"arm64", "amd64"}, supported},
+		{"affinity key", "kubernetes.io/arch", term.Key},
+		{"affinity values", []string{"arm64", "amd64"}, term.Values},
+		{"preferred weight", int32(2), affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight},
+		{"derived", []string{"amd64", "arm64"}, derived},
+		{"derived knative affinity", (*corev1.Affinity)(nil), derivedKnative.Affinity},
+		{"explicit knative affinity", []string{"arm64"}, explicitKnative.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values},
+		{"spread knative node affinity", (*corev1.NodeAffinity)(nil), spreadKnative.Affinity.NodeAffinity},
+		{"unsupported", 0, len(unsupported)},
+	}
+	verifyTests("architectures", tests, t)
+}
+
+func createAppsodyApp(n, ns string, spec appsodyv1alpha1.AppsodyApplicationSpec) *appsodyv1alpha1.AppsodyApplication {
+	app := &appsodyv1alpha1.AppsodyApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: n, Namespace: ns},
+		Spec:       spec,
+	}
+	return app
+}
+
+func verifyTests(n string, tests []Test, t *testing.T) {
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.actual, tt.expected) {
+			t.Errorf("%s %s test expected: (%v) actual: (%v)", n, tt.test, tt.expected, tt.actual)
+		}
+	}
+}
+
+func TestPromotion(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	image := "registry.example.com/my-app@" + digest
+	exposed := true
+	p := &appsodyv1alpha1.AppsodyPromotion{
+		ObjectMeta: metav1.ObjectMeta{Name: "promotion", Namespace: namespace},
+		Spec: appsodyv1alpha1.AppsodyPromotionSpec{
+			Source: appsodyv1alpha1.PromotionReference{Name: name},
+			Target: appsodyv1alpha1.PromotionReference{Name: name, Namespace: "prod"},
+			Fields: []string{"env", "resourceConstraints"},
+		},
+	}
+	_, approvedWithout := GetPromotionApproval(p, image)
+
+	invalid := p.DeepCopy()
+	invalid.Spec.Target.Namespace = ""
+	unknown := p.DeepCopy()
+	unknown.Spec.Fields = []string{"unknown"}
+
+	p.Spec.RequireApproval = true
+	_, approvedMissing := GetPromotionApproval(p, image)
+	p.Annotations = map[string]string{PromotionApprovalAnnotation: "sha256:" + strings.Repeat("b", 64)}
+	_, approvedOther := GetPromotionApproval(p, image)
+	p.Annotations[PromotionApprovalAnnotation] = digest
+	_, approvedDigest := GetPromotionApproval(p, image)
+
+	source := createAppsodyApp(name, namespace, appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage: "my-app:latest",
+		Env:              []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+		Expose:           &exposed,
+	})
+	target := createAppsodyApp(name, "prod", appsodyv1alpha1.AppsodyApplicationSpec{
+		ApplicationImage: "my-app:1.0",
+		Env:              []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+		ResourceConstraints: &corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+	})
+	changed, err := PromoteSpec(target, source, image, p.Spec.Fields)
+	if err != nil {
+		t.Fatalf("PromoteSpec: (%v)", err)
+	}
+	unchanged, err := PromoteSpec(target, source, image, p.Spec.Fields)
+	if err != nil {
+		t.Fatalf("PromoteSpec: (%v)", err)
+	}
+	AddPromotionRecord(p, image, digest)
+
+	source.Status.Conditions = []appsodyv1alpha1.StatusCondition{{Type: appsodyv1alpha1.StatusConditionTypeReconciled, Status: corev1.ConditionTrue}}
+	source.Generation, source.Status.ObservedGeneration = 2, 2
+	ready := IsApplicationReady(source)
+	source.Generation = 3
+	staleReady := IsApplicationReady(source)
+
+	testPromotion := []Test{
+		{"source namespace", namespace, GetPromotionSource(p).Namespace},
+		{"target namespace", "prod", GetPromotionTarget(p).Namespace},
+		{"valid", nil, ValidatePromotion(p)},
+		{"same application", true, ValidatePromotion(invalid) != nil},
+		{"unknown field", true, ValidatePromotion(unknown) != nil},
+		{"approval not required", true, approvedWithout},
+		{"approval missing", false, approvedMissing},
+		{"other digest approved", false, approvedOther},
+		{"digest approved", true, approvedDigest},
+		{"changed", true, changed},
+		{"unchanged", false, unchanged},
+		{"image", image, target.Spec.ApplicationImage},
+		{"env", "debug", target.Spec.Env[0].Value},
+		{"resources removed", true, target.Spec.ResourceConstraints == nil},
+		{"expose not promoted", true, target.Spec.Expose == nil},
+		{"history", 1, len(p.Status.History)},
+		{"ready", true, ready},
+		{"stale spec not ready", false, staleReady},
+	}
+	verifyTests("promotion", testPromotion, t)
+}
+
+func TestRevisionSpec(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	revision := int64(2)
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{ApplicationImage: "my-image:1.0", RollbackTo: &revision}
+	app := createAppsodyApp(name, namespace, spec)
+	unpinned := GetRevisionSpec(app)
+
+	app.Spec.ImagePolicy = &appsodyv1alpha1.AppsodyApplicationImagePolicy{}
+	pinnedImage, err := PinImageDigest(app.Spec.ApplicationImage, digest)
+	if err != nil {
+		t.Fatalf("PinImageDigest: (%v)", err)
+	}
+	RecordImageDigest(app, pinnedImage, digest)
+	pinned := GetRevisionSpec(app)
+
+	limit := int32(3)
+	defaultLimit := GetRevisionHistoryLimit(app)
+	app.Spec.RevisionHistoryLimit = &limit
+
+	testRevisionSpec := []Test{
+		{"unpinned image", "my-image:1.0", unpinned.ApplicationImage},
+		{"rollbackTo", true, unpinned.RollbackTo == nil},
+		{"rollbackTo of the application", revision, *app.Spec.RollbackTo},
+		{"pinned image", pinnedImage, pinned.ApplicationImage},
+		{"image policy", true, pinned.ImagePolicy == nil},
+		{"name", name + "-", GetRevisionName(app, []byte("{}"))[:len(name)+1]},
+		{"name length", len(name) + 11, len(GetRevisionName(app, []byte("{}")))},
+		{"same name", GetRevisionName(app, []byte("{}")), GetRevisionName(app, []byte("{}"))},
+		{"default limit", DefaultRevisionHistoryLimit, defaultLimit},
+		{"limit", 3, GetRevisionHistoryLimit(app)},
+	}
+	verifyTests("revisionSpec", testRevisionSpec, t)
+}
+
+func TestDependencies(t *testing.T) {
+	spec := appsodyv1alpha1.AppsodyApplicationSpec{
+		DependsOn: []appsodyv1alpha1.AppsodyApplicationDependency{{Name: "api"}, {Name: "db", Namespace: "data"}},
+	}
+	app := createAppsodyApp(name, namespace, spec)
+	dependencies := GetDependencies(app)
+	invalid := createAppsodyApp(name, namespace, appsodyv1alpha1.AppsodyApplicationSpec{
+		DependsOn: []appsodyv1alpha1.AppsodyApplicationDependency{{Namespace: "data"}},
+	})
+
+	testDependencies := []Test{
+		{"dependencies", 2, len(dependencies)},
+		{"default namespace", types.NamespacedName{Name: "api", Namespace: namespace}, dependencies[0]},
+		{"namespace", types.NamespacedName{Name: "db", Namespace: "data"}, dependencies[1]},
+		{"is dependency", true, IsDependency(app, types.NamespacedName{Name: "db", Namespace: "data"})},
+		{"other namespace", false, IsDependency(app, types.NamespacedName{Name: "db", Namespace: namespace})},
+		{"valid", nil, ValidateDependencies(app)},
+		{"missing name", true, ValidateDependencies(invalid) != nil},
+	}
+	verifyTests("dependencies", testDependencies, t)
+}
+
+func TestApplicationSet(t *testing.T) {
+	set := &appsodyv1alpha1.AppsodyApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: namespace, UID: "orders-uid"},
+		Spec: appsodyv1alpha1.AppsodyApplicationSetSpec{
+			Template: appsodyv1alpha1.AppsodyApplicationSetTemplate{
+				Metadata: appsodyv1alpha1.AppsodyApplicationSetTemplateMeta{Name: "orders-{{key}}", Namespace: "{{ namespace }}"},
+				Spec: appsodyv1alpha1.AppsodyApplicationSpec{
+					Stack:            "java-microprofile",
+					ApplicationImage: "registry.example.com/orders:{{version}}",
+				},
+			},
+		},
+	}
+	cm := &corev1.ConfigMap{Data: map[string]string{"globex": `{"version": "2.0", "namespace": "prod"}`, "acme": ""}}
+	rows, err := GetConfigMapParameters(cm)
+	if err != nil {
+		t.Fatalf("GetConfigMapParameters: (%v)", err)
+	}
+	_, missingErr := RenderApplicationSet(set, rows)
+	rows[0]["version"], rows[0]["namespace"] = "1.0", ""
+	apps, err := RenderApplicationSet(set, rows)
+	if err != nil {
+		t.Fatalf("RenderApplicationSet: (%v)", err)
+	}
+	_, duplicateErr := RenderApplicationSet(set, []map[string]string{rows[1], rows[1]})
+	_, invalidErr := GetConfigMapParameters(&corev1.ConfigMap{Data: map[string]string{"acme": "[]"}})
+
+	testRender := []Test{
+		{"rows", 2, len(rows)},
+		{"key", "acme", rows[0]["key"]},
+		{"missing parameter", "Parameters namespace, version of the template are not set by the generator", missingErr.Error()},
+		{"name", "orders-globex", apps[1].Name},
+		{"namespace", "prod", apps[1].Namespace},
+		{"default namespace", namespace, apps[0].Namespace},
+		{"image", "registry.example.com/orders:2.0", apps[1].Spec.ApplicationImage},
+		{"label", "orders", apps[0].Labels[ApplicationSetLabel]},
+		{"child", true, IsApplicationSetChild(apps[0], set)},
+		{"labeled only", false, IsApplicationSetChild(&appsodyv1alpha1.AppsodyApplication{ObjectMeta: metav1.ObjectMeta{Labels: apps[0].Labels}}, set)},
+		{"duplicate", true, duplicateErr != nil},
+		{"invalid row", true, invalidErr != nil},
+		{"valid", nil, ValidateApplicationSet(set)},
+	}
+	verifyTests("render", testRender, t)
+
+	replicas := int32(2)
+	app := apps[0].DeepCopy()
+	app.Spec.Replicas = &replicas
+	app.Spec.Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+	changed, err := MergeApplication(app, apps[0])
+	if err != nil {
+		t.Fatalf("MergeApplication: (%v)", err)
+	}
+	unchanged, _ := MergeApplication(app, apps[0])
+	rendered := apps[0].DeepCopy()
+	rendered.Spec.Stack = ""
+	MergeApplication(app, rendered)
+	zero := int32(0)
+	scaledDown := rendered.DeepCopy()
+	scaledDown.Spec.Replicas = &zero
+	scaledApp := app.DeepCopy()
+	MergeApplication(scaledApp, scaledDown)
+
+	testMerge := []Test{
+		{"changed", true, changed},
+		{"unchanged", false, unchanged},
+		{"replicas", replicas, *app.Spec.Replicas},
+		{"env", "bar", app.Spec.Env[0].Value},
+		{"removed stack", "", app.Spec.Stack},
+		{"fields", "applicationImage", app.Annotations[ApplicationSetFieldsAnnotation]},
+		{"zero replicas", zero, *scaledApp.Spec.Replicas},
+	}
+	verifyTests("merge", testMerge, t)
+
+	set.Spec.Generators = []appsodyv1alpha1.AppsodyApplicationSetGenerator{{}}
+	verifyTests("validate", []Test{{"no source", true, ValidateApplicationSet(set) != nil}}, t)
+}